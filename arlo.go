@@ -17,8 +17,9 @@
 package arlo
 
 import (
+	"context"
 	"fmt"
-	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,71 +32,175 @@ type Arlo struct {
 	user         string
 	pass         string
 	client       *request.Client
+	authClient   *request.Client // Talks to the authentication api, which lives on a different host.
 	Account      Account
-	Basestations Basestations
-	Cameras      Cameras
+	expires      time.Time // When the auth token expires, if it's known.
+	authApi      bool      // Whether the login went through the authentication api, rather than LoginV2Uri.
+	Devices      DeviceCollection
+	Basestations BasestationCollection
+	ArloQs       ArloQCollection
+	Bridges      BridgeCollection
+	Cameras      CameraCollection
+	Doorbells    DoorbellCollection
+	Chimes       ChimeCollection
+	Lights       LightCollection
+	Sirens       SirenCollection
+	devices      *collection // Shared by the collections above.
 	rwmutex      sync.RWMutex
+	listeners    *listeners
+	states       *stateStore // The latest known state of each device, kept up to date by the event stream.
+	streaming    map[string]bool
+
+	// The listeners of WatchDevices.
+	deviceListeners *deviceListeners
+
+	// All of the basestations share one connection to the event stream.
+	eventStream      *eventStream
+	eventStreamMutex sync.Mutex      // Serializes connecting and disconnecting the event stream.
+	subscribed       map[string]bool // The device ids of the basestations that are subscribed.
+
+	// Used to log in again when the auth token expires.
+	credentials      CredentialProvider
+	twoFactor        TwoFactorHandler
+	tokenStore       TokenStore
+	onReauthenticate func(err error)
+
+	notifyMutex    sync.Mutex
+	notifyLimit    RateLimit
+	notifyLimiters map[string]*request.Limiter // One for each basestation.
+
+	capabilitiesMutex   sync.Mutex
+	capabilitiesCache   map[string]*Capabilities      // By modelId. Nil for a model that the Arlo API doesn't have them for.
+	capabilitiesFetches map[string]*capabilitiesFetch // The fetches in flight, by modelId.
+
+	eventStreamTimeout time.Duration // How long to wait for the response to an event stream message.
+	pingInterval       time.Duration // How often the basestations are pinged to keep the event stream alive.
+	userAgent          string        // Sent with the requests, and to the event stream, if it isn't empty.
 }
 
-func newArlo(user string, pass string) (arlo *Arlo) {
-
-	// Add important headers.
-	baseHeaders := make(http.Header)
-	baseHeaders.Add("DNT", "1")
-	baseHeaders.Add("schemaVersion", "1")
-	baseHeaders.Add("Host", "my.arlo.com")
-	baseHeaders.Add("Referer", "https://my.arlo.com/")
+// Login logs in to an account that doesn't have two-factor authentication enabled.
+// Use LoginWithTwoFactor, or BeginLogin, for accounts that do.
+func Login(user string, pass string) (arlo *Arlo, err error) {
+	return LoginContext(context.Background(), user, pass)
+}
 
-	c, _ := request.NewClient(BaseUrl, baseHeaders)
+// LoginContext is like Login, but uses ctx for the login request and for fetching and subscribing to the devices.
+func LoginContext(ctx context.Context, user string, pass string) (arlo *Arlo, err error) {
+	if arlo, err = NewClient(); err != nil {
+		return nil, err
+	}
 
-	return &Arlo{
-		user:   user,
-		pass:   pass,
-		client: c,
+	if err := arlo.LoginContext(ctx, user, pass); err != nil {
+		return nil, err
 	}
+
+	return arlo, nil
 }
 
-func Login(user string, pass string) (arlo *Arlo, err error) {
-	arlo = newArlo(user, pass)
+// Login logs in an Arlo object made with NewClient, like the Login function does.
+func (a *Arlo) Login(user string, pass string) error {
+	return a.LoginContext(context.Background(), user, pass)
+}
+
+// LoginContext is like Login, but uses ctx for the login request and for fetching and subscribing to the devices.
+func (a *Arlo) LoginContext(ctx context.Context, user string, pass string) error {
+	a.setCredentials(user, pass)
+
+	account, err := a.login(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := a.loggedIn(ctx, account); err != nil {
+		return errors.WithMessage(err, "failed to login")
+	}
+
+	return nil
+}
 
-	body := map[string]string{"email": arlo.user, "password": arlo.pass}
-	resp, err := arlo.post(LoginV2Uri, "", body, nil)
+// login logs in with the username and password, without two-factor authentication.
+func (a *Arlo) login(ctx context.Context) (Account, error) {
+	user, pass := a.userCredentials()
+	body := map[string]string{"email": user, "password": pass}
+	resp, err := a.post(request.WithoutReauthentication(ctx), LoginV2Uri, "", body, nil)
 	if err != nil {
-		return nil, errors.WithMessage(err, "failed to login")
+		return Account{}, errors.WithMessage(err, "failed to login")
 	}
 	defer resp.Body.Close()
 
 	var loginResponse LoginResponse
 	if err := resp.Decode(&loginResponse); err != nil {
-		return nil, err
+		return Account{}, err
 	}
 
-	if loginResponse.Success {
-		// Cache the auth token.
-		arlo.client.AddHeader("Authorization", loginResponse.Data.Token)
+	if !loginResponse.Success {
+		return Account{}, errors.WithMessage(statusError(resp, loginResponse.Status), "failed to login")
+	}
 
-		// Save the account info with the arlo struct.
-		arlo.Account = loginResponse.Data
+	return loginResponse.Data, nil
+}
 
-		// Get the devices, which also caches them on the arlo object.
-		if _, err := arlo.GetDevices(); err != nil {
-			return nil, errors.WithMessage(err, "failed to login")
-		}
-	} else {
-		return nil, errors.New("failed to login")
-	}
+// loggedIn saves the account of a successful login and gets the devices.
+func (a *Arlo) loggedIn(ctx context.Context, account Account) error {
+	a.authenticated(account)
 
-	return arlo, nil
+	// Get the devices, which also caches them on the arlo object.
+	_, err := a.GetDevicesContext(ctx)
+	return err
+}
+
+// authenticated saves the account of a successful login.
+func (a *Arlo) authenticated(account Account) {
+	// Cache the auth token.
+	a.client.AddHeader("Authorization", account.Token)
+
+	// Save the account info with the arlo struct.
+	a.rwmutex.Lock()
+	a.Account = account
+	a.rwmutex.Unlock()
+}
+
+// CurrentAccount returns the account that's logged in. Unlike the Account field, it's safe to call while the Arlo
+// object logs in again because its auth token expired.
+func (a *Arlo) CurrentAccount() Account {
+	a.rwmutex.RLock()
+	defer a.rwmutex.RUnlock()
+	return a.Account
+}
+
+// setCredentials sets the username and password to log in with.
+func (a *Arlo) setCredentials(user, pass string) {
+	a.rwmutex.Lock()
+	defer a.rwmutex.Unlock()
+	a.user, a.pass = user, pass
+}
+
+// userCredentials returns the username and password to log in with.
+func (a *Arlo) userCredentials() (user, pass string) {
+	a.rwmutex.RLock()
+	defer a.rwmutex.RUnlock()
+	return a.user, a.pass
 }
 
 func (a *Arlo) Logout() error {
-	resp, err := a.put(LogoutUri, "", nil, nil)
+	return a.LogoutContext(context.Background())
+}
+
+// LogoutContext is like Logout, but uses ctx for the request.
+func (a *Arlo) LogoutContext(ctx context.Context) error {
+	a.disconnect()
+	resp, err := a.put(ctx, LogoutUri, "", nil, nil)
 	return checkRequest(resp, err, "failed to logout")
 }
 
 func (a *Arlo) CheckSession() (session *Session, err error) {
+	return a.CheckSessionContext(context.Background())
+}
+
+// CheckSessionContext is like CheckSession, but uses ctx for the request.
+func (a *Arlo) CheckSessionContext(ctx context.Context) (session *Session, err error) {
 	msg := "failed to get session"
-	resp, err := a.get(SessionUri, "", nil)
+	resp, err := a.get(ctx, SessionUri, "", nil)
 	if err != nil {
 		return nil, errors.WithMessage(err, msg)
 	}
@@ -107,7 +212,7 @@ func (a *Arlo) CheckSession() (session *Session, err error) {
 	}
 
 	if response.Success == false {
-		return nil, errors.WithMessage(errors.New(response.Reason), msg)
+		return nil, errors.WithMessage(statusError(resp, response.Status), msg)
 	}
 	return &response.Data, nil
 }
@@ -115,7 +220,41 @@ func (a *Arlo) CheckSession() (session *Session, err error) {
 // GetDevices returns an array of all devices.
 // When you call Login, this method is called and all devices are cached in the arlo object.
 func (a *Arlo) GetDevices() (devices *Devices, err error) {
-	resp, err := a.get(fmt.Sprintf(DevicesUri, time.Now().Format("20060102")), "", nil)
+	return a.GetDevicesContext(context.Background())
+}
+
+// GetDevicesContext is like GetDevices, but uses ctx for the request.
+func (a *Arlo) GetDevicesContext(ctx context.Context) (devices *Devices, err error) {
+	data, err := a.fetchDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Disconnect all of the basestations from the EventStream.
+	for _, b := range a.hubs() {
+		if err := b.Disconnect(); err != nil {
+			return nil, errors.WithMessage(err, "failed to get devices")
+		}
+	}
+
+	// Cache the devices as their respective types.
+	a.deviceListeners.dispatch(a.devices.replace(data))
+
+	a.states.seed(data)
+
+	// subscribe each basestation, Arlo Q and bridge to the EventStream.
+	for _, b := range a.hubs() {
+		if err := b.SubscribeContext(ctx); err != nil {
+			return nil, errors.WithMessage(err, "failed to get devices")
+		}
+	}
+
+	return &data, nil
+}
+
+// fetchDevices gets the devices from the Arlo API.
+func (a *Arlo) fetchDevices(ctx context.Context) (Devices, error) {
+	resp, err := a.get(ctx, fmt.Sprintf(DevicesUri, time.Now().Format("20060102")), "", nil)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to get devices")
 	}
@@ -127,7 +266,7 @@ func (a *Arlo) GetDevices() (devices *Devices, err error) {
 	}
 
 	if !response.Success {
-		return nil, errors.New("failed to get devices")
+		return nil, errors.WithMessage(statusError(resp, response.Status), "failed to get devices")
 	}
 
 	if len(response.Data) == 0 {
@@ -139,32 +278,89 @@ func (a *Arlo) GetDevices() (devices *Devices, err error) {
 		response.Data[i].arlo = a
 	}
 
-	// Disconnect all of the basestations from the EventStream.
-	for i := range a.Basestations {
-		if err := a.Basestations[i].Disconnect(); err != nil {
-			return nil, errors.WithMessage(err, "failed to get devices")
+	return response.Data, nil
+}
+
+// hubs returns the devices that connect to the event stream: the basestations, Arlo Qs and bridges.
+// The other devices are controlled through the hub that's their parent.
+func (a *Arlo) hubs() []*Basestation {
+	var hubs []*Basestation
+	for _, v := range a.devices.filter([]DeviceKind{KindBasestation, KindArloQ, KindBridge}, nil) {
+		switch v := v.(type) {
+		case *Basestation:
+			hubs = append(hubs, v)
+		case *ArloQ:
+			hubs = append(hubs, &v.Basestation)
+		case *Bridge:
+			hubs = append(hubs, &v.Basestation)
 		}
 	}
+	return hubs
+}
+
+// basestation returns the hub with the device id, or nil if there isn't one.
+func (a *Arlo) basestation(deviceId string) *Basestation {
+	switch v := a.devices.findId([]DeviceKind{KindBasestation, KindArloQ, KindBridge}, deviceId).(type) {
+	case *Basestation:
+		return v
+	case *ArloQ:
+		return &v.Basestation
+	case *Bridge:
+		return &v.Basestation
+	}
+	return nil
+}
 
+// Events returns a listener that receives every event stream message, from any basestation, that matches the filter.
+// This includes unsolicited events like motion detection, media upload notifications, battery changes, etc.
+// Call Unsubscribe() on the listener when you're done with it.
+func (a *Arlo) Events(filter EventFilter) *EventListener {
+	return a.listeners.add(filter, "")
+}
+
+// OnEvent calls handler for every event stream message, from any basestation, that matches the filter.
+// The handler is called from its own goroutine, one event at a time. Call Unsubscribe() on the returned listener to stop it.
+func (a *Arlo) OnEvent(filter EventFilter, handler func(*EventStreamResponse)) *EventListener {
+	return a.listeners.addFunc(filter, "", handler)
+}
+
+// setStreaming records whether the camera with the given device id has an active user stream.
+func (a *Arlo) setStreaming(deviceId string, streaming bool) {
 	a.rwmutex.Lock()
-	// Cache the devices as their respective types.
-	a.Cameras = *response.Data.GetCameras()
-	a.Basestations = *response.Data.GetBasestations()
-	a.rwmutex.Unlock()
+	defer a.rwmutex.Unlock()
+	if streaming {
+		a.streaming[deviceId] = true
+	} else {
+		delete(a.streaming, deviceId)
+	}
+}
 
-	// subscribe each basestation to the EventStream.
-	for i := range a.Basestations {
-		if err := a.Basestations[i].Subscribe(); err != nil {
-			return nil, errors.WithMessage(err, "failed to get devices")
-		}
+// streamEnded clears the streaming flag of a camera when the event stream reports that its user stream is over. The
+// stream can end without StopStream(), e.g. when nobody reads it, or the camera stops it.
+func (a *Arlo) streamEnded(event *EventStreamResponse) {
+	if !strings.HasPrefix(event.Resource, "cameras/") {
+		return
+	}
+	switch stringProperty(event.Properties, "activityState") {
+	case "idle", "stopUserStream":
+		a.setStreaming(strings.TrimPrefix(event.Resource, "cameras/"), false)
 	}
+}
 
-	return &response.Data, nil
+func (a *Arlo) isStreaming(deviceId string) bool {
+	a.rwmutex.RLock()
+	defer a.rwmutex.RUnlock()
+	return a.streaming[deviceId]
 }
 
 // GetProfile returns the user profile for the currently logged in user.
 func (a *Arlo) GetProfile() (profile *UserProfile, err error) {
-	resp, err := a.get(ProfileUri, "", nil)
+	return a.GetProfileContext(context.Background())
+}
+
+// GetProfileContext is like GetProfile, but uses ctx for the request.
+func (a *Arlo) GetProfileContext(ctx context.Context) (profile *UserProfile, err error) {
+	resp, err := a.get(ctx, ProfileUri, "", nil)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to get user profile")
 	}
@@ -176,7 +372,7 @@ func (a *Arlo) GetProfile() (profile *UserProfile, err error) {
 	}
 
 	if !response.Success {
-		return nil, errors.New("failed to get user profile")
+		return nil, errors.WithMessage(statusError(resp, response.Status), "failed to get user profile")
 	}
 
 	return &response.Data, nil
@@ -184,30 +380,51 @@ func (a *Arlo) GetProfile() (profile *UserProfile, err error) {
 
 // UpdateDisplayOrder sets the display order according to the order defined in the DeviceOrder given.
 func (a *Arlo) UpdateDisplayOrder(d DeviceOrder) error {
-	resp, err := a.post(CameraOrderUri, "", d, nil)
+	return a.UpdateDisplayOrderContext(context.Background(), d)
+}
+
+// UpdateDisplayOrderContext is like UpdateDisplayOrder, but uses ctx for the request.
+func (a *Arlo) UpdateDisplayOrderContext(ctx context.Context, d DeviceOrder) error {
+	resp, err := a.post(ctx, CameraOrderUri, "", d, nil)
 	return checkRequest(resp, err, "failed to display order")
 }
 
 // UpdateProfile takes a first and last name, and updates the user profile with that information.
 func (a *Arlo) UpdateProfile(firstName, lastName string) error {
+	return a.UpdateProfileContext(context.Background(), firstName, lastName)
+}
+
+// UpdateProfileContext is like UpdateProfile, but uses ctx for the request.
+func (a *Arlo) UpdateProfileContext(ctx context.Context, firstName, lastName string) error {
 	body := map[string]string{"firstName": firstName, "lastName": lastName}
-	resp, err := a.put(ProfileUri, "", body, nil)
+	resp, err := a.put(ctx, ProfileUri, "", body, nil)
 	return checkRequest(resp, err, "failed to update profile")
 }
 
 func (a *Arlo) UpdatePassword(pass string) error {
-	body := map[string]string{"currentPassword": a.pass, "newPassword": pass}
-	resp, err := a.post(UpdatePasswordUri, "", body, nil)
+	return a.UpdatePasswordContext(context.Background(), pass)
+}
+
+// UpdatePasswordContext is like UpdatePassword, but uses ctx for the request.
+func (a *Arlo) UpdatePasswordContext(ctx context.Context, pass string) error {
+	user, current := a.userCredentials()
+	body := map[string]string{"currentPassword": current, "newPassword": pass}
+	resp, err := a.post(ctx, UpdatePasswordUri, "", body, nil)
 	if err := checkRequest(resp, err, "failed to update password"); err != nil {
 		return err
 	}
 
-	a.pass = pass
+	a.setCredentials(user, pass)
 
 	return nil
 }
 
 func (a *Arlo) UpdateFriends(f Friend) error {
-	resp, err := a.put(FriendsUri, "", f, nil)
+	return a.UpdateFriendsContext(context.Background(), f)
+}
+
+// UpdateFriendsContext is like UpdateFriends, but uses ctx for the request.
+func (a *Arlo) UpdateFriendsContext(ctx context.Context, f Friend) error {
+	resp, err := a.put(ctx, FriendsUri, "", f, nil)
 	return checkRequest(resp, err, "failed to update friends")
 }
@@ -15,3 +15,1644 @@
  */
 
 package arlo
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeffreydwalter/arlo-go/arlotest"
+)
+
+func newTestArlo(t *testing.T) (*arlotest.Server, *Arlo) {
+	t.Helper()
+
+	srv := arlotest.NewServer()
+	srv.AddDevice(arlotest.Basestation("BASESTATION1"))
+	srv.AddDevice(arlotest.Camera("CAMERA1", "BASESTATION1"))
+
+	a, err := NewClient(WithBaseURL(srv.URL))
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	if err := a.Login(arlotest.DefaultUser, arlotest.DefaultPassword); err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	return srv, a
+}
+
+func closeTestArlo(srv *arlotest.Server, a *Arlo) {
+	a.Logout()
+	srv.Close()
+}
+
+func TestLogin(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	if a.Account.Token != srv.Token() {
+		t.Errorf("Account.Token = %q, want %q", a.Account.Token, srv.Token())
+	}
+	if a.Basestations.Len() != 1 || a.Cameras.Len() != 1 {
+		t.Fatalf("got %d basestations and %d cameras, want 1 and 1", a.Basestations.Len(), a.Cameras.Len())
+	}
+	if srv.Streams() != 1 {
+		t.Errorf("got %d event streams, want 1", srv.Streams())
+	}
+}
+
+func TestLoginBadPassword(t *testing.T) {
+	srv := arlotest.NewServer()
+	defer srv.Close()
+
+	a, err := NewClient(WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Login(arlotest.DefaultUser, "wrong"); !IsUnauthorized(err) {
+		t.Fatalf("Login() = %v, want an unauthorized error", err)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestNewClientOptions(t *testing.T) {
+	srv := arlotest.NewServer()
+	defer srv.Close()
+	srv.AddDevice(arlotest.Basestation("BASESTATION1"))
+
+	login := func(t *testing.T, opts ...Option) *Arlo {
+		t.Helper()
+		a, err := NewClient(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Login(arlotest.DefaultUser, arlotest.DefaultPassword); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+
+	t.Run("user agent", func(t *testing.T) {
+		a := login(t, WithBaseURL(srv.URL), WithUserAgent("arlo-go-test/1.0"))
+		defer a.Logout()
+
+		requests := srv.Requests()
+		if len(requests) == 0 {
+			t.Fatal("no requests were made")
+		}
+		for _, r := range requests {
+			if got := r.Header.Get("User-Agent"); got != "arlo-go-test/1.0" {
+				t.Errorf("%s has User-Agent %q, want %q", r.Path, got, "arlo-go-test/1.0")
+			}
+		}
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		proxyURL, err := url.Parse(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The host doesn't exist, so the requests only get to the server through the proxy.
+		a := login(t, WithBaseURL("http://arlo.invalid"), WithProxy(proxyURL))
+		defer a.Logout()
+
+		if _, err := a.GetDevices(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("TLS config", func(t *testing.T) {
+		tlsSrv := httptest.NewUnstartedServer(srv.Config.Handler)
+		tlsSrv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+		tlsSrv.StartTLS()
+		defer tlsSrv.Close()
+
+		// The server's certificate isn't trusted by default.
+		a, err := NewClient(WithBaseURL(tlsSrv.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Login(arlotest.DefaultUser, arlotest.DefaultPassword); err == nil {
+			t.Fatal("Login() without the TLS config succeeded, want a certificate error")
+		}
+
+		roots := x509.NewCertPool()
+		roots.AddCert(tlsSrv.Certificate())
+		a = login(t, WithBaseURL(tlsSrv.URL), WithTLSConfig(&tls.Config{RootCAs: roots}))
+		defer a.Logout()
+
+		// The TLS config isn't set on http.DefaultTransport, which other clients use.
+		other, err := NewClient(WithBaseURL(tlsSrv.URL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := other.Login(arlotest.DefaultUser, arlotest.DefaultPassword); err == nil {
+			t.Error("Login() of another client without the TLS config succeeded, want a certificate error")
+		}
+	})
+
+	t.Run("HTTP client", func(t *testing.T) {
+		var mutex sync.Mutex
+		var requests, redirects int
+		client := &http.Client{
+			Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				mutex.Lock()
+				requests++
+				mutex.Unlock()
+				return http.DefaultTransport.RoundTrip(r)
+			}),
+			CheckRedirect: func(r *http.Request, via []*http.Request) error {
+				mutex.Lock()
+				redirects++
+				mutex.Unlock()
+				return errors.New("redirects aren't allowed")
+			},
+			Timeout: time.Minute,
+		}
+		a := login(t, WithBaseURL(srv.URL), WithHTTPClient(client))
+		defer a.Logout()
+
+		if a.client.HttpClient.Timeout != time.Minute {
+			t.Errorf("Timeout = %v, want %v", a.client.HttpClient.Timeout, time.Minute)
+		}
+		if a.client.HttpClient.Jar == nil {
+			t.Error("no Jar was added to the client")
+		}
+		if client.Jar != nil {
+			t.Error("the caller's client was changed")
+		}
+
+		srv.Handle("GET", ProfileUri, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/elsewhere", http.StatusFound)
+		})
+		a.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+		if _, err := a.GetProfile(); err == nil || !strings.Contains(err.Error(), "redirects aren't allowed") {
+			t.Errorf("GetProfile() = %v, want the error of CheckRedirect", err)
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		if requests == 0 {
+			t.Error("the requests weren't made with the client's Transport")
+		}
+		if redirects != 1 {
+			t.Errorf("CheckRedirect was called %d times, want 1", redirects)
+		}
+	})
+
+	t.Run("not an http.Transport", func(t *testing.T) {
+		transport := roundTripFunc(http.DefaultTransport.RoundTrip)
+		proxyURL, _ := url.Parse(srv.URL)
+		for name, opts := range map[string][]Option{
+			"transport with proxy":        {WithTransport(transport), WithProxy(proxyURL)},
+			"transport with TLS config":   {WithTransport(transport), WithTLSConfig(&tls.Config{})},
+			"HTTP client with TLS config": {WithHTTPClient(&http.Client{Transport: transport}), WithTLSConfig(&tls.Config{})},
+		} {
+			if _, err := NewClient(opts...); err == nil || !strings.Contains(err.Error(), "*http.Transport") {
+				t.Errorf("%s: NewClient() = %v, want an error about the transport", name, err)
+			}
+		}
+
+		// Without the proxy and TLS options, any transport can be used.
+		if _, err := NewClient(WithTransport(transport)); err != nil {
+			t.Errorf("NewClient() = %v, want no error", err)
+		}
+	})
+}
+
+func TestSetCustomMode(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	b := a.Basestations.All()[0]
+
+	if _, err := b.SetCustomMode("mode2"); err != nil {
+		t.Fatal(err)
+	}
+
+	modes, err := b.GetModes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modes.Active != "mode2" {
+		t.Errorf("active mode = %q, want %q", modes.Active, "mode2")
+	}
+	if n := len(srv.Notifications()); n < 2 {
+		t.Errorf("got %d notifications, want at least 2", n)
+	}
+}
+
+func TestLight(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.AddDevice(arlotest.Light("LIGHT1", "BASESTATION1"))
+	if _, err := a.GetDevices(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Cameras.Len() != 1 || a.Lights.Len() != 1 {
+		t.Fatalf("got %d cameras and %d lights, want 1 and 1", a.Cameras.Len(), a.Lights.Len())
+	}
+	l := a.Lights.Find("LIGHT1")
+
+	if _, err := l.On(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.SetFlash("strobe"); err == nil {
+		t.Error("SetFlash(\"strobe\") succeeded, want an error")
+	}
+
+	response, err := l.GetState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stringProperty(response.Properties, "lampState"); got != "on" {
+		t.Errorf("lampState = %q, want %q", got, "on")
+	}
+}
+
+func TestStreamEnded(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	c := a.Cameras.Find("CAMERA1")
+
+	srv.Handle("POST", StartStreamUri, func(w http.ResponseWriter, r *http.Request) {
+		arlotest.Success(w, map[string]string{"url": "rtsp://stream.example.com/CAMERA1"})
+	})
+	srv.Handle("POST", TakeSnapshotUri, func(w http.ResponseWriter, r *http.Request) {
+		arlotest.Error(w, http.StatusInternalServerError, "500", "snapshot failed")
+	})
+	notStreaming := func(err error) bool {
+		return err != nil && strings.Contains(err.Error(), "is not streaming")
+	}
+
+	if _, err := c.TakeSnapshot(); !notStreaming(err) {
+		t.Fatalf("TakeSnapshot() before StartStream() = %v, want a not streaming error", err)
+	}
+
+	url, err := c.StartStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "rtsps://stream.example.com/CAMERA1" {
+		t.Errorf("StartStream() = %q, want the rtsps url", url)
+	}
+	// The snapshot fails on the server, but only after the guard let it through.
+	if _, err := c.TakeSnapshot(); err == nil || notStreaming(err) {
+		t.Fatalf("TakeSnapshot() while streaming = %v, want the server's error", err)
+	}
+
+	// The camera ends the stream on its own, without StopStream().
+	srv.Publish(arlotest.Message{Action: "is", Resource: "cameras/CAMERA1", From: "BASESTATION1", Properties: map[string]interface{}{"activityState": "idle"}})
+	for deadline := time.Now().Add(5 * time.Second); a.isStreaming("CAMERA1"); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the stream to end")
+		}
+	}
+	if _, err := c.TakeSnapshot(); !notStreaming(err) {
+		t.Errorf("TakeSnapshot() after the stream ended = %v, want a not streaming error", err)
+	}
+}
+
+// listenerCount returns how many event listeners are subscribed.
+func listenerCount(a *Arlo) int {
+	a.listeners.rwmutex.RLock()
+	defer a.listeners.rwmutex.RUnlock()
+	return len(a.listeners.set)
+}
+
+func TestTakeSnapshot(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	c := a.Cameras.Find("CAMERA1")
+
+	srv.Handle("POST", StartStreamUri, func(w http.ResponseWriter, r *http.Request) {
+		arlotest.Success(w, map[string]string{"url": "rtsp://stream.example.com/CAMERA1"})
+	})
+	srv.Handle("POST", TakeSnapshotUri, func(w http.ResponseWriter, r *http.Request) {
+		arlotest.Success(w, nil)
+		// The snapshot of another camera doesn't count.
+		srv.Publish(arlotest.Message{Resource: "mediaUploadNotification", From: "BASESTATION1", DeviceId: "CAMERA2", PresignedContentUrl: "https://snapshots.example.com/CAMERA2.jpg"})
+		srv.Publish(arlotest.Message{Resource: "mediaUploadNotification", From: "BASESTATION1", DeviceId: "CAMERA1", PresignedContentUrl: "https://snapshots.example.com/CAMERA1.jpg"})
+	})
+
+	if _, err := c.StartStream(); err != nil {
+		t.Fatal(err)
+	}
+
+	n := listenerCount(a)
+	url, err := c.TakeSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://snapshots.example.com/CAMERA1.jpg" {
+		t.Errorf("TakeSnapshot() = %q, want the presignedContentUrl of CAMERA1", url)
+	}
+	if got := listenerCount(a); got != n {
+		t.Errorf("%d listeners after TakeSnapshot(), want %d", got, n)
+	}
+}
+
+func TestFullFrameSnapshot(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	c := a.Cameras.Find("CAMERA1")
+
+	url := srv.URL + "/snapshots/CAMERA1.jpg"
+	image := []byte("\xff\xd8\xff\xe0 full-frame snapshot")
+	srv.Handle("GET", "/snapshots/CAMERA1.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(image)
+	})
+
+	// The camera answers the request, and then says that the snapshot is available.
+	srv.Respond(func(deviceId string, msg arlotest.Message) (arlotest.Message, bool) {
+		if msg.Resource == "cameras/CAMERA1" && stringProperty(msg.Properties, "activityState") == "fullFrameSnapshot" {
+			go srv.Publish(arlotest.Message{
+				Action:     "fullFrameSnapshotAvailable",
+				Resource:   "cameras/CAMERA1",
+				From:       "BASESTATION1",
+				Properties: map[string]interface{}{"presignedFullFrameSnapshotUrl": url},
+			})
+		}
+		return srv.DefaultResponse(deviceId, msg)
+	})
+
+	got, err := c.TriggerFullFrameSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != url {
+		t.Errorf("TriggerFullFrameSnapshot() = %q, want %q", got, url)
+	}
+
+	var buf bytes.Buffer
+	if got, err = c.DownloadFullFrameSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got != url || !bytes.Equal(buf.Bytes(), image) {
+		t.Errorf("DownloadFullFrameSnapshot() = %q, %q, want %q, %q", got, buf.Bytes(), url, image)
+	}
+}
+
+func TestSiren(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.AddDevice(arlotest.Siren("SIREN1", "BASESTATION1"))
+	if _, err := a.GetDevices(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Basestations.Len() != 1 || a.Sirens.Len() != 1 {
+		t.Fatalf("got %d basestations and %d sirens, want 1 and 1", a.Basestations.Len(), a.Sirens.Len())
+	}
+	s := a.Sirens.Find("SIREN1")
+
+	activations := make(chan bool, 2)
+	l := s.OnActivation(func(on bool) { activations <- on })
+	defer l.Unsubscribe()
+
+	if _, err := s.On(SirenOptions{Duration: 10, Volume: 20, Pattern: SirenPatternAlarm}); err != nil {
+		t.Fatal(err)
+	}
+	state, _ := srv.State("BASESTATION1", "sirens/SIREN1").(map[string]interface{})
+	if state["sirenState"] != "on" || state["volume"] != float64(SirenMaxVolume) {
+		t.Errorf("siren state = %v, want sirenState on at volume %d", state, SirenMaxVolume)
+	}
+
+	srv.Publish(arlotest.Message{
+		Action:     "is",
+		Resource:   "sirens/SIREN1",
+		From:       "BASESTATION1",
+		Properties: map[string]interface{}{"sirenState": "off"},
+	})
+	for _, want := range []bool{true, false} {
+		select {
+		case on := <-activations:
+			if on != want {
+				t.Errorf("OnActivation() got %t, want %t", on, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for the siren activation %t", want)
+		}
+	}
+
+	// The built-in siren of the basestation uses its own resource.
+	b := a.Basestations.All()[0]
+	if _, err := b.SirenOn(); err != nil {
+		t.Fatal(err)
+	}
+	response, err := b.Siren().GetState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stringProperty(response.Properties, "sirenState"); got != "on" {
+		t.Errorf("sirenState = %q, want %q", got, "on")
+	}
+}
+
+func TestDeviceKinds(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.AddDevice(arlotest.ArloQ("ARLOQ1"))
+	srv.AddDevice(arlotest.Bridge("BRIDGE1"))
+	srv.AddDevice(arlotest.Light("LIGHT1", "BRIDGE1"))
+	srv.AddDevice(arlotest.Doorbell("DOORBELL1", "BASESTATION1"))
+	srv.AddDevice(arlotest.Chime("CHIME1", "BASESTATION1"))
+	if _, err := a.GetDevices(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{
+		"basestations": a.Basestations.Len(),
+		"arlo qs":      a.ArloQs.Len(),
+		"bridges":      a.Bridges.Len(),
+		"cameras":      a.Cameras.Len(),
+		"doorbells":    a.Doorbells.Len(),
+		"chimes":       a.Chimes.Len(),
+		"lights":       a.Lights.Len(),
+	}
+	for name, n := range counts {
+		if n != 1 {
+			t.Errorf("got %d %s, want 1", n, name)
+		}
+	}
+
+	q := a.ArloQs.Find("ARLOQ1")
+	if q == nil || !q.Basestation.IsArloQ() {
+		t.Fatal("ARLOQ1 isn't an Arlo Q")
+	}
+	if _, ok := q.Basestation.Wrap().(*ArloQ); !ok {
+		t.Errorf("Wrap() = %T, want *ArloQ", q.Basestation.Wrap())
+	}
+
+	// The Arlo Q is its own basestation, and the light is controlled through the bridge.
+	if _, err := q.On(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.SetCustomMode("mode1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Lights.Find("LIGHT1").On(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Doorbells.Find("DOORBELL1").GetState(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Doorbells.Find("DOORBELL1").Camera() != nil {
+		t.Error("the audio doorbell has a camera")
+	}
+}
+
+func TestDeviceCollection(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.AddDevice(arlotest.Camera("CAMERA2", "BASESTATION1"))
+	if _, err := a.GetDevices(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := a.Cameras.Find("CAMERA1")
+	if c == nil {
+		t.Fatal("Find(CAMERA1) = nil")
+	}
+	if got := a.Cameras.FindByName("CAMERA1"); got != c {
+		t.Errorf("FindByName(CAMERA1) = %p, want %p", got, c)
+	}
+	if got := a.Cameras.FindByUniqueId(arlotest.DefaultUserId + "_CAMERA1"); got != c {
+		t.Errorf("FindByUniqueId() = %p, want %p", got, c)
+	}
+	if got := a.Cameras.FindByModel("VMC4030"); len(got) != 2 {
+		t.Errorf("FindByModel(VMC4030) returned %d cameras, want 2", len(got))
+	}
+	if a.Cameras.Find("BASESTATION1") != nil {
+		t.Error("Cameras.Find() returned a basestation")
+	}
+	if d := a.Devices.Find("BASESTATION1"); d == nil || d.Kind() != KindBasestation {
+		t.Errorf("Devices.Find(BASESTATION1) = %v, want the basestation", d)
+	}
+	if n := a.Devices.Len(); n != 3 {
+		t.Errorf("Devices.Len() = %d, want 3", n)
+	}
+
+	// An unchanged camera keeps its pointer across a refresh.
+	if _, err := a.RefreshDevices(); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Cameras.Find("CAMERA1"); got != c {
+		t.Errorf("Find(CAMERA1) after a refresh = %p, want %p", got, c)
+	}
+
+	// A camera that's held across refreshes that change its fields keeps its pointer, and sees the changes. Its
+	// snapshots, and the fields that address it, can be read while the refreshes change it.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			a.Cameras.Range(func(c *Camera) bool {
+				d := (*Device)(c).Snapshot()
+				return d.DeviceName != "" && d.FirmwareVersion != "x"
+			})
+			if d, ok := a.Basestations.Snapshot("BASESTATION1"); !ok || d.FirmwareVersion == "x" {
+				t.Error("Snapshot(BASESTATION1) failed")
+			}
+			d := (*Device)(c).Snapshot()
+			_ = d.DeviceName + d.PresignedLastImageUrl + c.ParentId + c.XCloudId
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		camera := arlotest.Camera("CAMERA1", "BASESTATION1")
+		camera.FirmwareVersion = fmt.Sprintf("1.%d", i)
+		camera.PresignedLastImageUrl = fmt.Sprintf("https://images.example.com/CAMERA1/%d.jpg", i)
+		srv.RemoveDevice("CAMERA1")
+		srv.AddDevice(camera)
+		if _, err := a.RefreshDevices(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+
+	if got := a.Cameras.Find("CAMERA1"); got != c {
+		t.Errorf("Find(CAMERA1) after a change = %p, want the same camera %p", got, c)
+	}
+	if c.FirmwareVersion != "1.4" || c.PresignedLastImageUrl != "https://images.example.com/CAMERA1/4.jpg" {
+		t.Errorf("camera after the refreshes = firmware %q, image %q, want the data of the last refresh", c.FirmwareVersion, c.PresignedLastImageUrl)
+	}
+
+	// A camera that moved to another basestation is a new one, since its methods address it by its parent.
+	camera := arlotest.Camera("CAMERA1", "BASESTATION2")
+	srv.RemoveDevice("CAMERA1")
+	srv.AddDevice(camera)
+	if _, err := a.RefreshDevices(); err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Cameras.Find("CAMERA1"); got == c || got.ParentId != "BASESTATION2" || c.ParentId != "BASESTATION1" {
+		t.Errorf("Find(CAMERA1) after a move = %+v, want a new camera on BASESTATION2", got)
+	}
+
+	// The slices returned by GetDevices return pointers into themselves.
+	devices := Devices{{DeviceId: "DEVICE1"}}
+	devices.Find("DEVICE1").DeviceName = "renamed"
+	if devices[0].DeviceName != "renamed" {
+		t.Error("Devices.Find() returned a copy")
+	}
+}
+
+func TestRefreshDevices(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	l := a.WatchDevices()
+	defer l.Unsubscribe()
+
+	c := a.Cameras.Find("CAMERA1")
+	camera := arlotest.Camera("CAMERA1", "BASESTATION1")
+	camera.DeviceName = "Front door"
+	camera.FirmwareVersion = "2.0"
+	srv.RemoveDevice("CAMERA1")
+	srv.AddDevice(camera)
+	srv.AddDevice(arlotest.Basestation("BASESTATION2"))
+	srv.AddDevice(arlotest.Camera("CAMERA2", "BASESTATION2"))
+
+	events, err := a.RefreshDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]DeviceEventType{}
+	for _, event := range events {
+		got[event.Device.DeviceId] = event.Type
+		if event.Type == DeviceChanged && strings.Join(event.Changes, ",") != "firmwareVersion,deviceName" {
+			t.Errorf("%s changes = %v, want [firmwareVersion deviceName]", event.Device.DeviceId, event.Changes)
+		}
+	}
+	want := map[string]DeviceEventType{"CAMERA1": DeviceChanged, "BASESTATION2": DeviceAdded, "CAMERA2": DeviceAdded}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("RefreshDevices() = %v, want %v", got, want)
+	}
+	if a.Cameras.Find("CAMERA1") != c || c.DeviceName != "Front door" {
+		t.Error("CAMERA1 wasn't updated in place with the changed camera")
+	}
+
+	// The new basestation joined the event stream that was already connected.
+	b := a.Basestations.Find("BASESTATION2")
+	if err := b.IsConnected(); err != nil {
+		t.Error(err)
+	}
+	connects := 0
+	for _, r := range srv.Requests() {
+		if r.Path == "/client/subscribe" {
+			connects++
+		}
+	}
+	if connects != 1 {
+		t.Errorf("connected to the event stream %d times, want 1", connects)
+	}
+
+	srv.RemoveDevice("BASESTATION2")
+	srv.RemoveDevice("CAMERA2")
+	if events, err = a.RefreshDevices(); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != DeviceRemoved || events[1].Type != DeviceRemoved {
+		t.Errorf("RefreshDevices() = %v, want 2 removed devices", events)
+	}
+	if b.IsConnected() == nil {
+		t.Error("BASESTATION2 is still connected after it was removed")
+	}
+	if err := a.Basestations.Find("BASESTATION1").IsConnected(); err != nil {
+		t.Error(err)
+	}
+
+	if n := len(l.Events); n != 5 {
+		t.Errorf("the listener got %d events, want 5", n)
+	}
+}
+
+func TestRefreshResubscribesChangedHub(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	b := arlotest.Basestation("BASESTATION1")
+	b.XCloudId = "XCLOUD-CHANGED"
+	srv.RemoveDevice("BASESTATION1")
+	srv.AddDevice(b)
+	if _, err := a.RefreshDevices(); err != nil {
+		t.Fatal(err)
+	}
+
+	// After a reconnect, the basestation is subscribed again with the data from the refresh.
+	srv.DropStreams()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var xCloudIds []string
+		for _, r := range srv.Requests() {
+			if strings.HasSuffix(r.Path, "/notify/BASESTATION1") {
+				xCloudIds = append(xCloudIds, r.Header.Get("xcloudId"))
+			}
+		}
+		if len(xCloudIds) > 0 && xCloudIds[len(xCloudIds)-1] == "XCLOUD-CHANGED" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("xcloudIds of the notify requests = %v, want XCLOUD-CHANGED last", xCloudIds)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRequestStreamDropped(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	b := a.Basestations.All()[0]
+
+	// The first request for the modes is lost with the event stream, so it has to be sent again once it's back.
+	var mutex sync.Mutex
+	var transIds []string
+	srv.Respond(func(deviceId string, msg arlotest.Message) (arlotest.Message, bool) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if msg.Resource == "modes" {
+			transIds = append(transIds, msg.TransId)
+			if len(transIds) == 1 {
+				srv.DropStreams()
+				return arlotest.Message{}, false
+			}
+		}
+		return srv.DefaultResponse(deviceId, msg)
+	})
+
+	if _, err := b.GetModes(); err != nil {
+		t.Fatal(err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(transIds) != 2 || transIds[0] != transIds[1] {
+		t.Errorf("transIds of the modes requests = %v, want the same one twice", transIds)
+	}
+}
+
+func TestNotifyEventStreamError(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	b := a.Basestations.Find("BASESTATION1")
+
+	srv.Handle("POST", fmt.Sprintf(NotifyUri, "BASESTATION1"), func(w http.ResponseWriter, r *http.Request) {
+		arlotest.Error(w, http.StatusBadRequest, "2059", "Device is offline.")
+	})
+
+	payload := EventStreamPayload{Action: "get", Resource: "modes", TransId: genTransId(), To: "BASESTATION1"}
+	err := b.NotifyEventStream(payload, "failed to get modes")
+	if err == nil {
+		t.Fatal("NotifyEventStream() succeeded, want an error")
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("NotifyEventStream() = %v, want an *APIError", err)
+	}
+	if apiErr.TransId != payload.TransId {
+		t.Errorf("TransId = %q, want %q", apiErr.TransId, payload.TransId)
+	}
+	if path := fmt.Sprintf(NotifyUri, "BASESTATION1"); !strings.HasPrefix(apiErr.Endpoint, "POST ") || !strings.HasSuffix(apiErr.Endpoint, path) {
+		t.Errorf("Endpoint = %q, want the POST to %s", apiErr.Endpoint, path)
+	}
+	if !IsDeviceOffline(err) {
+		t.Errorf("IsDeviceOffline(%v) = false, want true", err)
+	}
+	// The error is only wrapped once, with the message of the caller.
+	if n := strings.Count(err.Error(), "failed to"); n != 1 {
+		t.Errorf("error %q is wrapped %d times, want once", err, n)
+	}
+}
+
+// pendingRequests returns how many event stream requests are waiting for a response.
+func pendingRequests(a *Arlo) int {
+	es := a.stream()
+	es.subscriptions.rwmutex.RLock()
+	defer es.subscriptions.rwmutex.RUnlock()
+	return len(es.subscriptions.subscribers)
+}
+
+func TestContextDone(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	b := a.Basestations.Find("BASESTATION1")
+	c := a.Cameras.Find("CAMERA1")
+
+	// Nothing is answered, so every call waits until ctx is done.
+	srv.Handle("GET", ProfileUri, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	srv.Handle("POST", StartStreamUri, func(w http.ResponseWriter, r *http.Request) {
+		arlotest.Success(w, map[string]string{"url": "rtsp://stream.example.com/CAMERA1"})
+	})
+	srv.Handle("POST", TakeSnapshotUri, func(w http.ResponseWriter, r *http.Request) {
+		arlotest.Success(w, nil)
+	})
+	srv.Respond(func(deviceId string, msg arlotest.Message) (arlotest.Message, bool) {
+		return arlotest.Message{}, false
+	})
+	if _, err := c.StartStream(); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := map[string]func(ctx context.Context) error{
+		"http request": func(ctx context.Context) error {
+			_, err := a.GetProfileContext(ctx)
+			return err
+		},
+		"event stream request": func(ctx context.Context) error {
+			_, err := b.GetModesContext(ctx)
+			return err
+		},
+		"event listener": func(ctx context.Context) error {
+			_, err := c.TakeSnapshotContext(ctx)
+			return err
+		},
+	}
+	dones := map[string]struct {
+		ctx  func() (context.Context, context.CancelFunc)
+		want error
+	}{
+		"canceled": {func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+			return ctx, cancel
+		}, context.Canceled},
+		"deadline exceeded": {func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 50*time.Millisecond)
+		}, context.DeadlineExceeded},
+	}
+
+	for name, call := range calls {
+		for doneName, done := range dones {
+			t.Run(name+" "+doneName, func(t *testing.T) {
+				listeners := listenerCount(a)
+
+				ctx, cancel := done.ctx()
+				defer cancel()
+				start := time.Now()
+				err := call(ctx)
+				if !errors.Is(err, done.want) {
+					t.Errorf("got %v, want %v", err, done.want)
+				}
+				if d := time.Since(start); d > 5*time.Second {
+					t.Errorf("returned after %v, want as soon as ctx was done", d)
+				}
+
+				if n := listenerCount(a); n != listeners {
+					t.Errorf("%d listeners afterwards, want %d", n, listeners)
+				}
+				if n := pendingRequests(a); n != 0 {
+					t.Errorf("%d event stream requests still waiting, want 0", n)
+				}
+			})
+		}
+	}
+}
+
+func TestSharedEventStream(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.AddDevice(arlotest.Basestation("BASESTATION2"))
+	if _, err := a.RefreshDevices(); err != nil {
+		t.Fatal(err)
+	}
+	b1, b2 := a.Basestations.Find("BASESTATION1"), a.Basestations.Find("BASESTATION2")
+	if err := b2.IsConnected(); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Streams(); n != 1 {
+		t.Errorf("got %d event streams, want 1 shared by both basestations", n)
+	}
+
+	srv.SetState("BASESTATION1", "modes", map[string]interface{}{"active": "mode1"})
+	srv.SetState("BASESTATION2", "modes", map[string]interface{}{"active": "mode2"})
+
+	// Hold the modes requests until both have arrived, then answer them in the opposite order.
+	var mutex sync.Mutex
+	var pending []arlotest.Message
+	srv.Respond(func(deviceId string, msg arlotest.Message) (arlotest.Message, bool) {
+		if msg.Resource != "modes" {
+			return srv.DefaultResponse(deviceId, msg)
+		}
+		mutex.Lock()
+		pending = append(pending, msg)
+		ready := len(pending) == 2
+		mutex.Unlock()
+		if ready {
+			for i := len(pending) - 1; i >= 0; i-- {
+				response, _ := srv.DefaultResponse(pending[i].To, pending[i])
+				srv.Publish(response)
+			}
+		}
+		return arlotest.Message{}, false
+	})
+
+	active := make([]string, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, b := range []*Basestation{b1, b2} {
+		wg.Add(1)
+		go func(i int, b *Basestation) {
+			defer wg.Done()
+			modes, err := b.GetModes()
+			if err == nil {
+				active[i] = modes.Active
+			}
+			errs[i] = err
+		}(i, b)
+	}
+	wg.Wait()
+	for i, want := range []string{"mode1", "mode2"} {
+		if errs[i] != nil {
+			t.Errorf("BASESTATION%d GetModes() = %v", i+1, errs[i])
+		} else if active[i] != want {
+			t.Errorf("BASESTATION%d active mode = %q, want %q", i+1, active[i], want)
+		}
+	}
+	srv.Respond(nil)
+
+	// Disconnecting one basestation leaves the event stream to the other.
+	if err := b1.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if b1.IsConnected() == nil {
+		t.Error("BASESTATION1 is still connected after Disconnect()")
+	}
+	if err := b2.IsConnected(); err != nil {
+		t.Errorf("BASESTATION2 IsConnected() = %v, want nil", err)
+	}
+	if modes, err := b2.GetModes(); err != nil || modes.Active != "mode2" {
+		t.Errorf("BASESTATION2 GetModes() = %+v, %v, want mode2", modes, err)
+	}
+	if n := srv.Streams(); n != 1 {
+		t.Errorf("got %d event streams, want 1", n)
+	}
+}
+
+func TestXCloudIdPerRequest(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.AddDevice(arlotest.Basestation("BASESTATION2"))
+	if _, err := a.RefreshDevices(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each basestation's requests carry its own xcloudId, even when they're made at the same time.
+	var wg sync.WaitGroup
+	for _, b := range a.Basestations.All() {
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(b *Basestation) {
+				defer wg.Done()
+				if err := b.Ping(); err != nil {
+					t.Error(err)
+				}
+			}(b)
+		}
+	}
+	wg.Wait()
+
+	n := 0
+	for _, r := range srv.Requests() {
+		if i := strings.LastIndex(r.Path, "/notify/"); i >= 0 {
+			n++
+			deviceId := r.Path[i+len("/notify/"):]
+			if got := r.Header.Get("xcloudId"); got != "XCLOUD-"+deviceId {
+				t.Errorf("notify request to %s has xcloudId %q, want %q", deviceId, got, "XCLOUD-"+deviceId)
+			}
+		}
+	}
+	if n < 20 {
+		t.Errorf("got %d notify requests, want at least 20", n)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.SetCapabilities("VMC4030", map[string]interface{}{
+		"VMC4030": map[string]interface{}{
+			"Capabilities": map[string]interface{}{
+				"AudioPlayback": map[string]interface{}{"supported": true, "Volume": map[string]interface{}{"min": 0, "max": 100}},
+				"NightLight":    false,
+				"PushToTalk":    map[string]interface{}{"supported": false, "Siren": true},
+			},
+		},
+	})
+	c := a.Cameras.All()[0]
+
+	// Only the names of the capabilities are features, so the nested Siren flag isn't one.
+	for feature, want := range map[string]bool{FeatureAudioPlayback: true, FeatureNightLight: false, FeaturePushToTalk: false, FeatureSiren: false, "Volume": false} {
+		if got := c.Supports(feature); got != want {
+			t.Errorf("Supports(%q) = %t, want %t", feature, got, want)
+		}
+	}
+	caps, err := c.Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(caps.Features()); got != "[audioplayback]" {
+		t.Errorf("Features() = %s, want [audioplayback]", got)
+	}
+
+	if _, err := c.NightLight(true); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("NightLight() = %v, want %v", err, ErrUnsupported)
+	}
+	if _, err := c.SetVolume(50); err != nil {
+		t.Fatal(err)
+	}
+
+	// The capabilities are only fetched once, and a model without any is let through.
+	if _, err := a.Basestations.All()[0].SirenOn(); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, r := range srv.Requests() {
+		if strings.HasPrefix(r.Path, "/resources/capabilities/") {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d capabilities requests, want 2", n)
+	}
+}
+
+func TestCapabilitiesFetchedOnce(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+	c := a.Cameras.All()[0]
+
+	var mutex sync.Mutex
+	requests := 0
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	srv.Handle("GET", "/resources/capabilities/en/en_"+c.ModelId+"|i1000.json", func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		mutex.Unlock()
+		arrived <- struct{}{}
+
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			c.ModelId: map[string]interface{}{"Capabilities": map[string]interface{}{"NightLight": true}},
+		})
+	})
+
+	// The first caller gives up while the request is in flight, so the others make it again, but only once.
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.CapabilitiesContext(ctx)
+		first <- err
+	}()
+	<-arrived
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]*Capabilities, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			caps, err := c.Capabilities()
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = caps
+		}(i)
+	}
+
+	// Give the callers time to start waiting for the first request, then cancel it.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("CapabilitiesContext() = %v, want %v", err, context.Canceled)
+	}
+	<-arrived
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if requests != 2 {
+		t.Errorf("got %d capabilities requests, want 2", requests)
+	}
+	for i, caps := range results {
+		if caps == nil || caps != results[0] {
+			t.Errorf("caller %d got %p, want the same capabilities as the others", i, caps)
+		}
+	}
+	if !c.Supports(FeatureNightLight) {
+		t.Error("the capabilities weren't cached")
+	}
+}
+
+func TestTypedState(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.SetState("BASESTATION1", "modes", map[string]interface{}{
+		"active": "mode1",
+		"modes": []interface{}{
+			map[string]interface{}{"id": "mode0", "name": "", "type": "disarmed", "rulesIds": []string{}},
+			map[string]interface{}{"id": "mode1", "name": "", "type": "armed", "rulesIds": []string{"rule1"}},
+		},
+		"unknown": 42,
+	})
+	srv.SetState("BASESTATION1", "cameras", []interface{}{
+		map[string]interface{}{"serialNumber": "CAMERA1", "batteryLevel": 87, "nightLight": map[string]interface{}{"enabled": true, "brightness": 150}},
+	})
+
+	b := a.Basestations.All()[0]
+	modes, err := b.GetModes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modes.Modes) != 2 || modes.Modes[1].RulesIds[0] != "rule1" {
+		t.Errorf("GetModes() modes = %+v, want mode0 and mode1 with rule1", modes.Modes)
+	}
+	if !strings.Contains(string(modes.Raw), `"unknown":42`) {
+		t.Errorf("GetModes() raw = %s, want the unknown field", modes.Raw)
+	}
+
+	cameras, err := b.GetAssociatedCamerasState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cameras) != 1 || cameras[0].BatteryLevel != 87 {
+		t.Fatalf("GetAssociatedCamerasState() = %+v, want CAMERA1 at 87%%", cameras)
+	}
+
+	nightLight, err := a.Cameras.All()[0].GetNightLight()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !nightLight.Enabled || nightLight.Brightness != 150 {
+		t.Errorf("GetNightLight() = %+v, want enabled at brightness 150", nightLight)
+	}
+}
+
+func TestDeviceState(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	state, ok := a.DeviceState("CAMERA1")
+	if !ok || state.Properties["deviceName"] != "CAMERA1" {
+		t.Fatalf("DeviceState() = %+v, %t, want the device data of CAMERA1", state, ok)
+	}
+	// The state is a copy.
+	state.Properties["deviceName"] = "changed"
+
+	l := a.WatchDeviceState("CAMERA1")
+	defer l.Unsubscribe()
+
+	srv.Publish(arlotest.Message{
+		Action:     "is",
+		Resource:   "cameras/CAMERA1",
+		From:       "BASESTATION1",
+		Properties: map[string]interface{}{"batteryLevel": 42, "privacyActive": true},
+	})
+
+	select {
+	case state := <-l.Changes:
+		var camera CameraState
+		if err := state.Decode(&camera); err != nil {
+			t.Fatal(err)
+		}
+		if camera.BatteryLevel != 42 || !camera.PrivacyActive || state.Properties["deviceName"] != "CAMERA1" {
+			t.Errorf("changed state = %+v, want battery 42 and privacy on", state.Properties)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the state to change")
+	}
+
+	if _, err := a.Basestations.All()[0].SetCustomMode("mode2"); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := a.DeviceState("BASESTATION1"); state.Mode != "mode2" {
+		t.Errorf("basestation mode = %q, want %q", state.Mode, "mode2")
+	}
+
+	// The built-in siren has a state of its own, which is kept apart from the basestation's.
+	bl := a.WatchDeviceState("BASESTATION1")
+	defer bl.Unsubscribe()
+	srv.Publish(arlotest.Message{
+		Action:     "is",
+		Resource:   "siren",
+		From:       "BASESTATION1",
+		Properties: map[string]interface{}{"sirenState": "on", "state": "on"},
+	})
+	select {
+	case state := <-bl.Changes:
+		siren, _ := state.Properties["siren"].(map[string]interface{})
+		if siren["sirenState"] != "on" || siren["state"] != "on" || state.Properties["state"] == "on" || state.Properties["sirenState"] != nil {
+			t.Errorf("changed state = %+v, want the siren's properties under siren", state.Properties)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the state to change")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	if h, ok := a.CameraHealth("CAMERA1"); !ok || h.BatteryLevel != -1 || h.SignalStrength != -1 {
+		t.Errorf("CameraHealth() = %+v, %t, want unknown battery and signal", h, ok)
+	}
+
+	alerts := make(chan HealthAlert, 10)
+	m := a.OnHealthAlert(HealthThresholds{Battery: 20, Signal: 2}, func(alert HealthAlert) {
+		alerts <- alert
+	})
+	defer m.Unsubscribe()
+
+	publish := func(properties map[string]interface{}) {
+		srv.Publish(arlotest.Message{Action: "is", Resource: "cameras/CAMERA1", From: "BASESTATION1", Properties: properties})
+	}
+	next := func() HealthAlert {
+		select {
+		case alert := <-alerts:
+			return alert
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a health alert")
+			return HealthAlert{}
+		}
+	}
+
+	publish(map[string]interface{}{"batteryLevel": 15, "signalStrength": 4, "chargingState": "On", "lastSeen": 1500000000000})
+	if alert := next(); alert.Type != HealthLowBattery || alert.Cleared || alert.Health.BatteryLevel != 15 {
+		t.Errorf("alert = %+v, want a low battery", alert)
+	}
+
+	publish(map[string]interface{}{"batteryLevel": 50, "signalStrength": 1})
+	got := map[HealthAlertType]bool{}
+	for i := 0; i < 2; i++ {
+		alert := next()
+		got[alert.Type] = alert.Cleared
+	}
+	if want := map[HealthAlertType]bool{HealthLowBattery: true, HealthLowSignal: false}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("alerts cleared = %v, want %v", got, want)
+	}
+
+	h, _ := a.Cameras.Find("CAMERA1").Health()
+	if h.BatteryLevel != 50 || h.SignalStrength != 1 || !h.Charging() || h.LastSeen.Unix() != 1500000000 {
+		t.Errorf("Health() = %+v", h)
+	}
+	if health := a.CamerasHealth(); len(health) != 1 || health[0].DeviceId != "CAMERA1" {
+		t.Errorf("CamerasHealth() = %+v, want CAMERA1", health)
+	}
+}
+
+func TestHealthAlertCurrentState(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.Publish(arlotest.Message{Action: "is", Resource: "basestation", From: "BASESTATION1", Properties: map[string]interface{}{"batteryLevel": 5}})
+	srv.Publish(arlotest.Message{Action: "is", Resource: "cameras/CAMERA1", From: "BASESTATION1", Properties: map[string]interface{}{"batteryLevel": 15}})
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		h, _ := a.CameraHealth("CAMERA1")
+		if state, _ := a.DeviceState("BASESTATION1"); h.BatteryLevel == 15 && state.Properties["batteryLevel"] != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the battery levels")
+		}
+	}
+
+	alerts := make(chan HealthAlert, 10)
+	m := a.OnHealthAlert(HealthThresholds{Battery: 20}, func(alert HealthAlert) {
+		alerts <- alert
+	})
+	defer m.Unsubscribe()
+
+	select {
+	case alert := <-alerts:
+		if alert.Type != HealthLowBattery || alert.Cleared || alert.Health.DeviceId != "CAMERA1" {
+			t.Errorf("alert = %+v, want a low battery on CAMERA1", alert)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a health alert")
+	}
+
+	srv.Publish(arlotest.Message{Action: "is", Resource: "basestation", From: "BASESTATION1", Properties: map[string]interface{}{"batteryLevel": 4}})
+	select {
+	case alert := <-alerts:
+		t.Errorf("got alert %+v, want none for a basestation", alert)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestEvents(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	l := a.Events(EventFilter{Resource: "cameras/CAMERA1"})
+	defer l.Unsubscribe()
+
+	srv.Publish(arlotest.Message{
+		Action:     "is",
+		Resource:   "cameras/CAMERA1",
+		From:       "BASESTATION1",
+		Properties: map[string]interface{}{"motionDetected": true},
+	})
+
+	select {
+	case event := <-l.Events:
+		if event.From != "BASESTATION1" {
+			t.Errorf("event.From = %q, want %q", event.From, "BASESTATION1")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+}
+
+// twoFactorHandler picks the factor of a type, and answers with a code.
+type twoFactorHandler struct {
+	factorType  string
+	code        string
+	trustDevice bool
+}
+
+func (h twoFactorHandler) SelectFactor(factors []Factor) (Factor, error) {
+	for _, f := range factors {
+		if f.FactorType == h.factorType {
+			return f, nil
+		}
+	}
+	return Factor{}, errors.New("no factor of type " + h.factorType)
+}
+
+func (h twoFactorHandler) Code(factor Factor) (string, bool, error) {
+	return h.code, h.trustDevice, nil
+}
+
+func newTwoFactorServer(t *testing.T) (*arlotest.Server, *Arlo) {
+	t.Helper()
+
+	srv := arlotest.NewServer()
+	srv.AddDevice(arlotest.Basestation("BASESTATION1"))
+	srv.EnableTwoFactor("123456",
+		arlotest.Factor{FactorId: "FACTOR1", FactorType: FactorTypeSMS, DisplayName: "555-0100"},
+		arlotest.Factor{FactorId: "FACTOR2", FactorType: FactorTypeEmail, DisplayName: arlotest.DefaultUser},
+	)
+
+	a, err := NewClient(WithBaseURL(srv.URL), WithAuthURL(srv.URL))
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return srv, a
+}
+
+func TestLoginTwoFactorSteps(t *testing.T) {
+	srv, a := newTwoFactorServer(t)
+	defer closeTestArlo(srv, a)
+
+	auth, err := a.BeginLogin(arlotest.DefaultUser, arlotest.DefaultPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth.TwoFactorRequired() {
+		t.Fatal("TwoFactorRequired() = false, want true")
+	}
+	if _, err := auth.Finish(); !errors.Is(err, ErrTwoFactorRequired) {
+		t.Fatalf("Finish() = %v, want %v", err, ErrTwoFactorRequired)
+	}
+
+	factors, err := auth.Factors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(factors) != 2 {
+		t.Fatalf("Factors() returned %d factors, want 2", len(factors))
+	}
+	if err := auth.RequestCode(factors[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	// The type of the factor that was picked is sent.
+	var startAuth struct {
+		FactorId   string `json:"factorId"`
+		FactorType string `json:"factorType"`
+	}
+	for _, r := range srv.Requests() {
+		if r.Path == "/startAuth" {
+			json.Unmarshal(r.Body, &startAuth)
+		}
+	}
+	if startAuth.FactorId != "FACTOR2" || startAuth.FactorType != FactorTypeEmail {
+		t.Errorf("startAuth factor = %+v, want FACTOR2 of type %s", startAuth, FactorTypeEmail)
+	}
+
+	if _, err := auth.SubmitCode("000000", false); err == nil {
+		t.Fatal("SubmitCode() with the wrong code succeeded")
+	}
+	if _, err := auth.SubmitCode("123456", true); err != nil {
+		t.Fatal(err)
+	}
+	if a.CurrentAccount().Token != srv.Token() || a.Basestations.Len() != 1 {
+		t.Errorf("logged in with token %q and %d basestations, want %q and 1", a.CurrentAccount().Token, a.Basestations.Len(), srv.Token())
+	}
+
+	// The device was trusted, so it doesn't need the second factor again.
+	if auth, err = a.BeginLogin(arlotest.DefaultUser, arlotest.DefaultPassword); err != nil {
+		t.Fatal(err)
+	}
+	if auth.TwoFactorRequired() {
+		t.Error("TwoFactorRequired() = true for a trusted device")
+	}
+}
+
+func TestLoginWithTwoFactor(t *testing.T) {
+	srv, a := newTwoFactorServer(t)
+	defer closeTestArlo(srv, a)
+
+	if err := a.LoginWithTwoFactor(arlotest.DefaultUser, arlotest.DefaultPassword, twoFactorHandler{factorType: FactorTypeSMS, code: "654321"}); err == nil {
+		t.Fatal("LoginWithTwoFactor() with the wrong code succeeded")
+	}
+
+	if err := a.LoginWithTwoFactor(arlotest.DefaultUser, arlotest.DefaultPassword, twoFactorHandler{factorType: FactorTypeSMS, code: "123456"}); err != nil {
+		t.Fatal(err)
+	}
+	if a.CurrentAccount().Token != srv.Token() {
+		t.Errorf("token = %q, want %q", a.CurrentAccount().Token, srv.Token())
+	}
+
+	// The device wasn't trusted, so the second factor is needed again.
+	auth, err := a.BeginLogin(arlotest.DefaultUser, arlotest.DefaultPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth.TwoFactorRequired() {
+		t.Error("TwoFactorRequired() = false for a device that wasn't trusted")
+	}
+}
+
+func TestReauthenticate(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	token := srv.Token()
+	srv.ExpireToken()
+
+	if _, err := a.CheckSession(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Account.Token == token || a.Account.Token != srv.Token() {
+		t.Errorf("Account.Token = %q, want the new token %q", a.Account.Token, srv.Token())
+	}
+}
+
+func TestReauthenticateExpiredSessionBody(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	reauthenticated := 0
+	a.OnReauthenticate(func(err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		reauthenticated++
+	})
+
+	// The session expired, but the server says so with 200 OK and error 2015.
+	token := srv.Token()
+	srv.ExpireTokenWithStatus(http.StatusOK)
+
+	if _, err := a.CheckSession(); err != nil {
+		t.Fatal(err)
+	}
+	if reauthenticated != 1 {
+		t.Errorf("reauthenticated %d times, want 1", reauthenticated)
+	}
+	if account := a.CurrentAccount(); account.Token == token || account.Token != srv.Token() {
+		t.Errorf("CurrentAccount().Token = %q, want the new token %q", account.Token, srv.Token())
+	}
+}
+
+func TestTokenStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arlo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	saved := &SavedSession{
+		Token:   "TOKEN",
+		UserId:  "USER",
+		Email:   arlotest.DefaultUser,
+		Cookies: map[string][]*http.Cookie{"https://my.arlo.com": {{Name: "browser_trust_token", Value: "TRUSTED"}}},
+		Expires: time.Now().Add(time.Hour).Round(time.Second),
+		AuthApi: true,
+	}
+
+	for name, store := range map[string]TokenStore{"file": NewFileTokenStore(path), "memory": NewMemoryTokenStore()} {
+		if _, err := store.Load(); err != ErrNoSession {
+			t.Errorf("%s: Load() of an empty store = %v, want %v", name, err, ErrNoSession)
+		}
+		if err := store.Save(saved); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		session, err := store.Load()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if session.Token != saved.Token || session.UserId != saved.UserId || session.Email != saved.Email || !session.Expires.Equal(saved.Expires) || !session.AuthApi {
+			t.Errorf("%s: Load() = %+v, want %+v", name, session, saved)
+		}
+		if cookies := session.Cookies["https://my.arlo.com"]; len(cookies) != 1 || cookies[0].Value != "TRUSTED" {
+			t.Errorf("%s: cookies = %v, want the trust cookie", name, session.Cookies)
+		}
+
+		if err := store.Clear(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if _, err := store.Load(); err != ErrNoSession {
+			t.Errorf("%s: Load() after Clear() = %v, want %v", name, err, ErrNoSession)
+		}
+		if err := store.Clear(); err != nil {
+			t.Errorf("%s: Clear() of an empty store = %v, want nil", name, err)
+		}
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{\"token\":"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileTokenStore(path).Load(); err == nil || err == ErrNoSession {
+		t.Errorf("Load() of a corrupt file = %v, want a parse error", err)
+	}
+	if _, err := Resume(NewFileTokenStore(filepath.Join(dir, "missing.json"))); !errors.Is(err, ErrNoSession) {
+		t.Errorf("Resume() from a missing file = %v, want %v", err, ErrNoSession)
+	}
+}
+
+func TestResumeSession(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		twoFactor bool
+		loginPath string // Where the resumed session logs in again when its token expires.
+	}{
+		{"login", false, "/login/v2"},
+		{"two-factor", true, "/auth"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			handler := twoFactorHandler{factorType: FactorTypeSMS, code: "123456"}
+			var srv *arlotest.Server
+			var a *Arlo
+			if test.twoFactor {
+				srv, a = newTwoFactorServer(t)
+				if err := a.LoginWithTwoFactor(arlotest.DefaultUser, arlotest.DefaultPassword, handler); err != nil {
+					srv.Close()
+					t.Fatal(err)
+				}
+			} else {
+				srv, a = newTestArlo(t)
+			}
+			defer closeTestArlo(srv, a)
+
+			dir, err := ioutil.TempDir("", "arlo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			store := NewFileTokenStore(filepath.Join(dir, "session.json"))
+			if err := a.SaveSession(store); err != nil {
+				t.Fatal(err)
+			}
+
+			resumed, err := NewClient(WithBaseURL(srv.URL), WithAuthURL(srv.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := resumed.Resume(store); err != nil {
+				t.Fatal(err)
+			}
+			defer resumed.Logout()
+			if resumed.CurrentAccount().Token != srv.Token() || resumed.Basestations.Len() != 1 {
+				t.Errorf("resumed with token %q and %d basestations, want %q and 1", resumed.CurrentAccount().Token, resumed.Basestations.Len(), srv.Token())
+			}
+
+			resumed.SetCredentialProvider(func(ctx context.Context) (string, string, error) {
+				return arlotest.DefaultUser, arlotest.DefaultPassword, nil
+			})
+			resumed.SetTwoFactorHandler(handler)
+			n := len(srv.Requests())
+			srv.ExpireToken()
+			if _, err := resumed.CheckSession(); err != nil {
+				t.Fatal(err)
+			}
+
+			var paths []string
+			for _, r := range srv.Requests()[n:] {
+				if r.Path == "/login/v2" || r.Path == "/auth" {
+					paths = append(paths, r.Path)
+				}
+			}
+			if len(paths) != 1 || paths[0] != test.loginPath {
+				t.Errorf("logged in again with %v, want %s", paths, test.loginPath)
+			}
+		})
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	srv, a := newTestArlo(t)
+	defer closeTestArlo(srv, a)
+
+	srv.AddRecording(arlotest.Recording{DeviceId: "CAMERA1", CreatedDate: "20181012", UtcCreatedDate: 1539360000000, Name: "one"})
+	srv.AddRecording(arlotest.Recording{DeviceId: "CAMERA1", CreatedDate: "20181013", UtcCreatedDate: 1539446400000, Name: "two"})
+
+	from := time.Date(2018, 10, 12, 0, 0, 0, 0, time.UTC)
+	library, err := a.GetLibrary(from, from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*library) != 1 || (*library)[0].Name != "one" {
+		t.Fatalf("GetLibrary() = %+v, want recording one", *library)
+	}
+
+	if err := a.DeleteRecording(&(*library)[0]); err != nil {
+		t.Fatal(err)
+	}
+	if recordings := srv.Recordings(); len(recordings) != 1 || recordings[0].Name != "two" {
+		t.Errorf("recordings after delete = %+v, want recording two", recordings)
+	}
+}
@@ -17,6 +17,8 @@
 package arlo
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -34,6 +36,11 @@ They may apply to other camera types that have audio playback or nightlight capa
 
 // SetVolume sets the volume of the audio playback to a level from 0-100.
 func (c *Camera) SetVolume(volume int) (response *EventStreamResponse, err error) {
+	return c.SetVolumeContext(context.Background(), volume)
+}
+
+// SetVolumeContext is like SetVolume, but uses ctx for the request.
+func (c *Camera) SetVolumeContext(ctx context.Context, volume int) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -50,16 +57,25 @@ func (c *Camera) SetVolume(volume int) (response *EventStreamResponse, err error
 
 	msg := "failed to set audio volume"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // Mute mutes the audio playback.
 func (c *Camera) Mute() (response *EventStreamResponse, err error) {
+	return c.MuteContext(context.Background())
+}
+
+// MuteContext is like Mute, but uses ctx for the request.
+func (c *Camera) MuteContext(ctx context.Context) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -75,16 +91,25 @@ func (c *Camera) Mute() (response *EventStreamResponse, err error) {
 
 	msg := "failed to mute audio"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // UnMute un-mutes the audio playback.
 func (c *Camera) UnMute() (response *EventStreamResponse, err error) {
+	return c.UnMuteContext(context.Background())
+}
+
+// UnMuteContext is like UnMute, but uses ctx for the request.
+func (c *Camera) UnMuteContext(ctx context.Context) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -100,16 +125,25 @@ func (c *Camera) UnMute() (response *EventStreamResponse, err error) {
 
 	msg := "failed to un-mute audio"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // Play plays an audio track, specified by the track ID, from a given position starting from 0 seconds.
 func (c *Camera) Play(trackId string, position int) error {
+	return c.PlayContext(context.Background(), trackId, position)
+}
+
+// PlayContext is like Play, but uses ctx for the request.
+func (c *Camera) PlayContext(ctx context.Context, trackId string, position int) error {
 
 	// Defaulting to 'hugh little baby', which is a supplied track. Hopefully, the ID is the same for everyone.
 	if trackId == "" {
@@ -131,13 +165,17 @@ func (c *Camera) Play(trackId string, position int) error {
 
 	msg := "failed to play audio"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return errors.WithMessage(err, msg)
 	}
 
-	if err := b.NotifyEventStream(payload, msg); err != nil {
+	if err := b.NotifyEventStreamContext(ctx, payload, msg); err != nil {
 		return errors.WithMessage(err, msg)
 	}
 	return nil
@@ -145,6 +183,11 @@ func (c *Camera) Play(trackId string, position int) error {
 
 // Pause pauses audio playback.
 func (c *Camera) Pause() error {
+	return c.PauseContext(context.Background())
+}
+
+// PauseContext is like Pause, but uses ctx for the request.
+func (c *Camera) PauseContext(ctx context.Context) error {
 	payload := EventStreamPayload{
 		Action:          "pause",
 		Resource:        "audioPlayback/player",
@@ -155,13 +198,17 @@ func (c *Camera) Pause() error {
 
 	msg := "failed to pause audio"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return errors.WithMessage(err, msg)
 	}
 
-	if err := b.NotifyEventStream(payload, msg); err != nil {
+	if err := b.NotifyEventStreamContext(ctx, payload, msg); err != nil {
 		return errors.WithMessage(err, msg)
 	}
 	return nil
@@ -169,6 +216,11 @@ func (c *Camera) Pause() error {
 
 // Next moves audio playback to the next track.
 func (c *Camera) Next() error {
+	return c.NextContext(context.Background())
+}
+
+// NextContext is like Next, but uses ctx for the request.
+func (c *Camera) NextContext(ctx context.Context) error {
 	payload := EventStreamPayload{
 		Action:          "nextTrack",
 		Resource:        "audioPlayback/player",
@@ -179,13 +231,17 @@ func (c *Camera) Next() error {
 
 	msg := "failed to skip audio"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return errors.WithMessage(err, msg)
 	}
 
-	if err := b.NotifyEventStream(payload, msg); err != nil {
+	if err := b.NotifyEventStreamContext(ctx, payload, msg); err != nil {
 		return errors.WithMessage(err, msg)
 	}
 	return nil
@@ -193,6 +249,11 @@ func (c *Camera) Next() error {
 
 // Shuffle toggles the audio play back mode to shuffle or not.
 func (c *Camera) Shuffle(on bool) (response *EventStreamResponse, err error) {
+	return c.ShuffleContext(context.Background(), on)
+}
+
+// ShuffleContext is like Shuffle, but uses ctx for the request.
+func (c *Camera) ShuffleContext(ctx context.Context, on bool) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        "audioPlayback/config",
@@ -213,23 +274,42 @@ func (c *Camera) Shuffle(on bool) (response *EventStreamResponse, err error) {
 		msg = "failed to disable shuffle"
 	}
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) Continuous() (response *EventStreamResponse, err error) {
-	return c.SetLoopBackMode("continuous")
+	return c.ContinuousContext(context.Background())
+}
+
+// ContinuousContext is like Continuous, but uses ctx for the request.
+func (c *Camera) ContinuousContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return c.SetLoopBackModeContext(ctx, "continuous")
 }
 
 func (c *Camera) SingleTrack() (response *EventStreamResponse, err error) {
-	return c.SetLoopBackMode("singleTrack")
+	return c.SingleTrackContext(context.Background())
+}
+
+// SingleTrackContext is like SingleTrack, but uses ctx for the request.
+func (c *Camera) SingleTrackContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return c.SetLoopBackModeContext(ctx, "singleTrack")
 }
 
 func (c *Camera) SetLoopBackMode(loopbackMode string) (response *EventStreamResponse, err error) {
+	return c.SetLoopBackModeContext(context.Background(), loopbackMode)
+}
+
+// SetLoopBackModeContext is like SetLoopBackMode, but uses ctx for the request.
+func (c *Camera) SetLoopBackModeContext(ctx context.Context, loopbackMode string) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        "audioPlayback/config",
@@ -245,15 +325,24 @@ func (c *Camera) SetLoopBackMode(loopbackMode string) (response *EventStreamResp
 
 	msg := "failed to set loop back mode to %s"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, fmt.Sprintf(msg, loopbackMode))
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
+}
+
+func (c *Camera) GetAudioPlayback() (playback *AudioPlaybackState, err error) {
+	return c.GetAudioPlaybackContext(context.Background())
 }
 
-func (c *Camera) GetAudioPlayback() (response *EventStreamResponse, err error) {
+// GetAudioPlaybackContext is like GetAudioPlayback, but uses ctx for the request.
+func (c *Camera) GetAudioPlaybackContext(ctx context.Context) (playback *AudioPlaybackState, err error) {
 	payload := EventStreamPayload{
 		Action:          "get",
 		Resource:        "audioPlayback",
@@ -264,15 +353,34 @@ func (c *Camera) GetAudioPlayback() (response *EventStreamResponse, err error) {
 
 	msg := "failed to get audio playback"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+
+	response, err := b.makeEventStreamRequest(ctx, payload, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	playback = &AudioPlaybackState{}
+	if err := decodeState(response, playback, &playback.Raw, msg); err != nil {
+		return nil, err
+	}
+	return playback, nil
 }
 
 func (c *Camera) EnableSleepTimer(sleepTime int64 /* milliseconds */, sleepTimeRel int) (response *EventStreamResponse, err error) {
+	return c.EnableSleepTimerContext(context.Background(), sleepTime, sleepTimeRel)
+}
+
+// EnableSleepTimerContext is like EnableSleepTimer, but uses ctx for the request.
+func (c *Camera) EnableSleepTimerContext(ctx context.Context, sleepTime int64 /* milliseconds */, sleepTimeRel int) (response *EventStreamResponse, err error) {
 	if sleepTime == 0 {
 		sleepTime = 300 + (time.Now().UnixNano() / 1000000) /* milliseconds */
 	}
@@ -293,15 +401,24 @@ func (c *Camera) EnableSleepTimer(sleepTime int64 /* milliseconds */, sleepTimeR
 
 	msg := "failed to enable sleep timer"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) DisableSleepTimer(sleepTimeRel int) (response *EventStreamResponse, err error) {
+	return c.DisableSleepTimerContext(context.Background(), sleepTimeRel)
+}
+
+// DisableSleepTimerContext is like DisableSleepTimer, but uses ctx for the request.
+func (c *Camera) DisableSleepTimerContext(ctx context.Context, sleepTimeRel int) (response *EventStreamResponse, err error) {
 	if sleepTimeRel == 0 {
 		sleepTimeRel = 300
 	}
@@ -322,20 +439,77 @@ func (c *Camera) DisableSleepTimer(sleepTimeRel int) (response *EventStreamRespo
 
 	msg := "failed to disable sleep timer"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureAudioPlayback); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 /*
 The follow methods are all related to the nightlight features of Arlo Baby.
 
-NOTE: The current state is in: cameras[0]["properties"][0]["nightLight"] returned from the basestation.GetAssociatedCamerasState() method.
+NOTE: The current state is in the NightLight of the camera's CameraState, returned from the basestation.GetAssociatedCamerasState() method,
+which is what GetNightLight() returns.
 */
+
+// GetNightLight returns the state of the night light.
+func (c *Camera) GetNightLight() (nightLight *NightLightState, err error) {
+	return c.GetNightLightContext(context.Background())
+}
+
+// GetNightLightContext is like GetNightLight, but uses ctx for the request.
+func (c *Camera) GetNightLightContext(ctx context.Context) (nightLight *NightLightState, err error) {
+	msg := "failed to get night light"
+
+	if err := (*Device)(c).require(ctx, FeatureNightLight); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
+	if b == nil {
+		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	cameras, err := b.GetAssociatedCamerasStateContext(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	for _, state := range cameras {
+		if state.SerialNumber != c.DeviceId {
+			continue
+		}
+
+		nightLight = &NightLightState{}
+		if state.NightLight != nil {
+			nightLight.BaseNightLightProperties = *state.NightLight
+		}
+		var raw struct {
+			NightLight json.RawMessage `json:"nightLight"`
+		}
+		if json.Unmarshal(state.Raw, &raw) == nil {
+			nightLight.Raw = raw.NightLight
+		}
+		return nightLight, nil
+	}
+
+	err = fmt.Errorf("camera (%s) not found in the state of basestation (%s)", c.DeviceId, c.ParentId)
+	return nil, errors.WithMessage(err, msg)
+}
+
 func (c *Camera) NightLight(on bool) (response *EventStreamResponse, err error) {
+	return c.NightLightContext(context.Background(), on)
+}
+
+// NightLightContext is like NightLight, but uses ctx for the request.
+func (c *Camera) NightLightContext(ctx context.Context, on bool) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -356,15 +530,24 @@ func (c *Camera) NightLight(on bool) (response *EventStreamResponse, err error)
 		msg = "failed to turn night light off"
 	}
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureNightLight); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) SetNightLightBrightness(level int) (response *EventStreamResponse, err error) {
+	return c.SetNightLightBrightnessContext(context.Background(), level)
+}
+
+// SetNightLightBrightnessContext is like SetNightLightBrightness, but uses ctx for the request.
+func (c *Camera) SetNightLightBrightnessContext(ctx context.Context, level int) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -380,16 +563,25 @@ func (c *Camera) SetNightLightBrightness(level int) (response *EventStreamRespon
 
 	msg := "failed to set night light brightness"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureNightLight); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // SetNightLightMode set the night light mode. Valid values are: "rainbow" or "rgb".
 func (c *Camera) SetNightLightMode(mode string) (response *EventStreamResponse, err error) {
+	return c.SetNightLightModeContext(context.Background(), mode)
+}
+
+// SetNightLightModeContext is like SetNightLightMode, but uses ctx for the request.
+func (c *Camera) SetNightLightModeContext(ctx context.Context, mode string) (response *EventStreamResponse, err error) {
 	msg := "failed to set night light brightness"
 
 	if mode != "rainbow" && mode != "rgb" {
@@ -409,16 +601,25 @@ func (c *Camera) SetNightLightMode(mode string) (response *EventStreamResponse,
 		To:   c.ParentId,
 	}
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureNightLight); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // SetNightLightColor sets the night light color to the RGB value specified by the three parameters, which have valid values from 0-255.
 func (c *Camera) SetNightLightColor(red, blue, green int) (response *EventStreamResponse, err error) {
+	return c.SetNightLightColorContext(context.Background(), red, blue, green)
+}
+
+// SetNightLightColorContext is like SetNightLightColor, but uses ctx for the request.
+func (c *Camera) SetNightLightColorContext(ctx context.Context, red, blue, green int) (response *EventStreamResponse, err error) {
 	// Sanity check; if the values are above or below the allowed limits, set them to their limit.
 	if red < 0 {
 		red = 0
@@ -455,15 +656,24 @@ func (c *Camera) SetNightLightColor(red, blue, green int) (response *EventStream
 
 	msg := "failed to set night light color"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureNightLight); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) EnableNightLightTimer(sleepTime int64 /* milliseconds */, sleepTimeRel int) (response *EventStreamResponse, err error) {
+	return c.EnableNightLightTimerContext(context.Background(), sleepTime, sleepTimeRel)
+}
+
+// EnableNightLightTimerContext is like EnableNightLightTimer, but uses ctx for the request.
+func (c *Camera) EnableNightLightTimerContext(ctx context.Context, sleepTime int64 /* milliseconds */, sleepTimeRel int) (response *EventStreamResponse, err error) {
 	if sleepTime == 0 {
 		sleepTime = 300 + (time.Now().UnixNano() / 1000000) /* milliseconds */
 	}
@@ -484,15 +694,24 @@ func (c *Camera) EnableNightLightTimer(sleepTime int64 /* milliseconds */, sleep
 
 	msg := "failed to enable night light timer"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureNightLight); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) DisableNightLightTimer(sleepTimeRel int) (response *EventStreamResponse, err error) {
+	return c.DisableNightLightTimerContext(context.Background(), sleepTimeRel)
+}
+
+// DisableNightLightTimerContext is like DisableNightLightTimer, but uses ctx for the request.
+func (c *Camera) DisableNightLightTimerContext(ctx context.Context, sleepTimeRel int) (response *EventStreamResponse, err error) {
 	if sleepTimeRel == 0 {
 		sleepTimeRel = 300
 	}
@@ -513,10 +732,14 @@ func (c *Camera) DisableNightLightTimer(sleepTimeRel int) (response *EventStream
 
 	msg := "failed to disable night light timer"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	if err := (*Device)(c).require(ctx, FeatureNightLight); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlomock
+
+import (
+	"context"
+	"io"
+	"time"
+
+	arlo "github.com/jeffreydwalter/arlo-go"
+)
+
+var (
+	_ arlo.LibraryService = (*Arlo)(nil)
+	_ arlo.AccountService = (*Arlo)(nil)
+	_ arlo.DeviceService  = (*Arlo)(nil)
+)
+
+// Arlo is a mock of arlo.LibraryService, arlo.AccountService and arlo.DeviceService.
+// Each method calls the func field of the same name, or returns zero values if it's nil.
+type Arlo struct {
+	Recorder
+
+	GetLibraryMetaDataContextFunc    func(ctx context.Context, fromDate time.Time, toDate time.Time) (*arlo.LibraryMetaData, error)
+	GetLibraryContextFunc            func(ctx context.Context, fromDate time.Time, toDate time.Time) (*arlo.Library, error)
+	DeleteRecordingContextFunc       func(ctx context.Context, r *arlo.Recording) error
+	BatchDeleteRecordingsContextFunc func(ctx context.Context, l *arlo.Library) error
+	SendAnalyticFeedbackContextFunc  func(ctx context.Context, r *arlo.Recording) error
+	DownloadFileContextFunc          func(ctx context.Context, url string, w io.Writer) error
+	LogoutContextFunc                func(ctx context.Context) error
+	CheckSessionContextFunc          func(ctx context.Context) (*arlo.Session, error)
+	GetProfileContextFunc            func(ctx context.Context) (*arlo.UserProfile, error)
+	UpdateDisplayOrderContextFunc    func(ctx context.Context, d arlo.DeviceOrder) error
+	UpdateProfileContextFunc         func(ctx context.Context, firstName string, lastName string) error
+	UpdatePasswordContextFunc        func(ctx context.Context, pass string) error
+	UpdateFriendsContextFunc         func(ctx context.Context, f arlo.Friend) error
+	GetDevicesContextFunc            func(ctx context.Context) (*arlo.Devices, error)
+	RefreshDevicesContextFunc        func(ctx context.Context) ([]arlo.DeviceEvent, error)
+}
+
+func (m *Arlo) GetLibraryMetaDataContext(ctx context.Context, fromDate time.Time, toDate time.Time) (*arlo.LibraryMetaData, error) {
+	m.add("GetLibraryMetaDataContext", ctx, fromDate, toDate)
+	if m.GetLibraryMetaDataContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetLibraryMetaDataContextFunc(ctx, fromDate, toDate)
+}
+
+func (m *Arlo) GetLibraryContext(ctx context.Context, fromDate time.Time, toDate time.Time) (*arlo.Library, error) {
+	m.add("GetLibraryContext", ctx, fromDate, toDate)
+	if m.GetLibraryContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetLibraryContextFunc(ctx, fromDate, toDate)
+}
+
+func (m *Arlo) DeleteRecordingContext(ctx context.Context, r *arlo.Recording) error {
+	m.add("DeleteRecordingContext", ctx, r)
+	if m.DeleteRecordingContextFunc == nil {
+		return nil
+	}
+	return m.DeleteRecordingContextFunc(ctx, r)
+}
+
+func (m *Arlo) BatchDeleteRecordingsContext(ctx context.Context, l *arlo.Library) error {
+	m.add("BatchDeleteRecordingsContext", ctx, l)
+	if m.BatchDeleteRecordingsContextFunc == nil {
+		return nil
+	}
+	return m.BatchDeleteRecordingsContextFunc(ctx, l)
+}
+
+func (m *Arlo) SendAnalyticFeedbackContext(ctx context.Context, r *arlo.Recording) error {
+	m.add("SendAnalyticFeedbackContext", ctx, r)
+	if m.SendAnalyticFeedbackContextFunc == nil {
+		return nil
+	}
+	return m.SendAnalyticFeedbackContextFunc(ctx, r)
+}
+
+func (m *Arlo) DownloadFileContext(ctx context.Context, url string, w io.Writer) error {
+	m.add("DownloadFileContext", ctx, url, w)
+	if m.DownloadFileContextFunc == nil {
+		return nil
+	}
+	return m.DownloadFileContextFunc(ctx, url, w)
+}
+
+func (m *Arlo) LogoutContext(ctx context.Context) error {
+	m.add("LogoutContext", ctx)
+	if m.LogoutContextFunc == nil {
+		return nil
+	}
+	return m.LogoutContextFunc(ctx)
+}
+
+func (m *Arlo) CheckSessionContext(ctx context.Context) (*arlo.Session, error) {
+	m.add("CheckSessionContext", ctx)
+	if m.CheckSessionContextFunc == nil {
+		return nil, nil
+	}
+	return m.CheckSessionContextFunc(ctx)
+}
+
+func (m *Arlo) GetProfileContext(ctx context.Context) (*arlo.UserProfile, error) {
+	m.add("GetProfileContext", ctx)
+	if m.GetProfileContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetProfileContextFunc(ctx)
+}
+
+func (m *Arlo) UpdateDisplayOrderContext(ctx context.Context, d arlo.DeviceOrder) error {
+	m.add("UpdateDisplayOrderContext", ctx, d)
+	if m.UpdateDisplayOrderContextFunc == nil {
+		return nil
+	}
+	return m.UpdateDisplayOrderContextFunc(ctx, d)
+}
+
+func (m *Arlo) UpdateProfileContext(ctx context.Context, firstName string, lastName string) error {
+	m.add("UpdateProfileContext", ctx, firstName, lastName)
+	if m.UpdateProfileContextFunc == nil {
+		return nil
+	}
+	return m.UpdateProfileContextFunc(ctx, firstName, lastName)
+}
+
+func (m *Arlo) UpdatePasswordContext(ctx context.Context, pass string) error {
+	m.add("UpdatePasswordContext", ctx, pass)
+	if m.UpdatePasswordContextFunc == nil {
+		return nil
+	}
+	return m.UpdatePasswordContextFunc(ctx, pass)
+}
+
+func (m *Arlo) UpdateFriendsContext(ctx context.Context, f arlo.Friend) error {
+	m.add("UpdateFriendsContext", ctx, f)
+	if m.UpdateFriendsContextFunc == nil {
+		return nil
+	}
+	return m.UpdateFriendsContextFunc(ctx, f)
+}
+
+func (m *Arlo) GetDevicesContext(ctx context.Context) (*arlo.Devices, error) {
+	m.add("GetDevicesContext", ctx)
+	if m.GetDevicesContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetDevicesContextFunc(ctx)
+}
+
+func (m *Arlo) RefreshDevicesContext(ctx context.Context) ([]arlo.DeviceEvent, error) {
+	m.add("RefreshDevicesContext", ctx)
+	if m.RefreshDevicesContextFunc == nil {
+		return nil, nil
+	}
+	return m.RefreshDevicesContextFunc(ctx)
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlomock
+
+import (
+	"context"
+
+	arlo "github.com/jeffreydwalter/arlo-go"
+)
+
+var (
+	_ arlo.BasestationController = (*Basestation)(nil)
+)
+
+// Basestation is a mock of arlo.BasestationController.
+// Each method calls the func field of the same name, or returns zero values if it's nil.
+type Basestation struct {
+	Recorder
+
+	IsConnectedFunc                      func() error
+	SubscribeContextFunc                 func(ctx context.Context) error
+	UnsubscribeContextFunc               func(ctx context.Context) error
+	DisconnectFunc                       func() error
+	PingContextFunc                      func(ctx context.Context) error
+	NotifyEventStreamContextFunc         func(ctx context.Context, payload arlo.EventStreamPayload, msg string) error
+	GetStateContextFunc                  func(ctx context.Context) (*arlo.BasestationState, error)
+	GetAssociatedCamerasStateContextFunc func(ctx context.Context) ([]arlo.CameraState, error)
+	GetRulesContextFunc                  func(ctx context.Context) (*arlo.RulesList, error)
+	GetCalendarModeContextFunc           func(ctx context.Context) (*arlo.ScheduleState, error)
+	SetCalendarModeContextFunc           func(ctx context.Context, active bool) (*arlo.EventStreamResponse, error)
+	GetModesContextFunc                  func(ctx context.Context) (*arlo.ModesList, error)
+	SetCustomModeContextFunc             func(ctx context.Context, mode string) (*arlo.EventStreamResponse, error)
+	DeleteModeContextFunc                func(ctx context.Context, mode string) (*arlo.EventStreamResponse, error)
+	ArmContextFunc                       func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	DisarmContextFunc                    func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	SirenOnContextFunc                   func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	SirenOffContextFunc                  func(ctx context.Context) (*arlo.EventStreamResponse, error)
+}
+
+func (m *Basestation) IsConnected() error {
+	m.add("IsConnected")
+	if m.IsConnectedFunc == nil {
+		return nil
+	}
+	return m.IsConnectedFunc()
+}
+
+func (m *Basestation) SubscribeContext(ctx context.Context) error {
+	m.add("SubscribeContext", ctx)
+	if m.SubscribeContextFunc == nil {
+		return nil
+	}
+	return m.SubscribeContextFunc(ctx)
+}
+
+func (m *Basestation) UnsubscribeContext(ctx context.Context) error {
+	m.add("UnsubscribeContext", ctx)
+	if m.UnsubscribeContextFunc == nil {
+		return nil
+	}
+	return m.UnsubscribeContextFunc(ctx)
+}
+
+func (m *Basestation) Disconnect() error {
+	m.add("Disconnect")
+	if m.DisconnectFunc == nil {
+		return nil
+	}
+	return m.DisconnectFunc()
+}
+
+func (m *Basestation) PingContext(ctx context.Context) error {
+	m.add("PingContext", ctx)
+	if m.PingContextFunc == nil {
+		return nil
+	}
+	return m.PingContextFunc(ctx)
+}
+
+func (m *Basestation) NotifyEventStreamContext(ctx context.Context, payload arlo.EventStreamPayload, msg string) error {
+	m.add("NotifyEventStreamContext", ctx, payload, msg)
+	if m.NotifyEventStreamContextFunc == nil {
+		return nil
+	}
+	return m.NotifyEventStreamContextFunc(ctx, payload, msg)
+}
+
+func (m *Basestation) GetStateContext(ctx context.Context) (*arlo.BasestationState, error) {
+	m.add("GetStateContext", ctx)
+	if m.GetStateContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetStateContextFunc(ctx)
+}
+
+func (m *Basestation) GetAssociatedCamerasStateContext(ctx context.Context) ([]arlo.CameraState, error) {
+	m.add("GetAssociatedCamerasStateContext", ctx)
+	if m.GetAssociatedCamerasStateContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetAssociatedCamerasStateContextFunc(ctx)
+}
+
+func (m *Basestation) GetRulesContext(ctx context.Context) (*arlo.RulesList, error) {
+	m.add("GetRulesContext", ctx)
+	if m.GetRulesContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetRulesContextFunc(ctx)
+}
+
+func (m *Basestation) GetCalendarModeContext(ctx context.Context) (*arlo.ScheduleState, error) {
+	m.add("GetCalendarModeContext", ctx)
+	if m.GetCalendarModeContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetCalendarModeContextFunc(ctx)
+}
+
+func (m *Basestation) SetCalendarModeContext(ctx context.Context, active bool) (*arlo.EventStreamResponse, error) {
+	m.add("SetCalendarModeContext", ctx, active)
+	if m.SetCalendarModeContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetCalendarModeContextFunc(ctx, active)
+}
+
+func (m *Basestation) GetModesContext(ctx context.Context) (*arlo.ModesList, error) {
+	m.add("GetModesContext", ctx)
+	if m.GetModesContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetModesContextFunc(ctx)
+}
+
+func (m *Basestation) SetCustomModeContext(ctx context.Context, mode string) (*arlo.EventStreamResponse, error) {
+	m.add("SetCustomModeContext", ctx, mode)
+	if m.SetCustomModeContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetCustomModeContextFunc(ctx, mode)
+}
+
+func (m *Basestation) DeleteModeContext(ctx context.Context, mode string) (*arlo.EventStreamResponse, error) {
+	m.add("DeleteModeContext", ctx, mode)
+	if m.DeleteModeContextFunc == nil {
+		return nil, nil
+	}
+	return m.DeleteModeContextFunc(ctx, mode)
+}
+
+func (m *Basestation) ArmContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("ArmContext", ctx)
+	if m.ArmContextFunc == nil {
+		return nil, nil
+	}
+	return m.ArmContextFunc(ctx)
+}
+
+func (m *Basestation) DisarmContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("DisarmContext", ctx)
+	if m.DisarmContextFunc == nil {
+		return nil, nil
+	}
+	return m.DisarmContextFunc(ctx)
+}
+
+func (m *Basestation) SirenOnContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("SirenOnContext", ctx)
+	if m.SirenOnContextFunc == nil {
+		return nil, nil
+	}
+	return m.SirenOnContextFunc(ctx)
+}
+
+func (m *Basestation) SirenOffContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("SirenOffContext", ctx)
+	if m.SirenOffContextFunc == nil {
+		return nil, nil
+	}
+	return m.SirenOffContextFunc(ctx)
+}
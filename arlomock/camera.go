@@ -0,0 +1,370 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlomock
+
+import (
+	"context"
+	"io"
+	"time"
+
+	arlo "github.com/jeffreydwalter/arlo-go"
+)
+
+var (
+	_ arlo.CameraController   = (*Camera)(nil)
+	_ arlo.ArloBabyController = (*Camera)(nil)
+)
+
+// Camera is a mock of arlo.CameraController and arlo.ArloBabyController.
+// Each method calls the func field of the same name, or returns zero values if it's nil.
+type Camera struct {
+	Recorder
+
+	OnContextFunc                          func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	OffContextFunc                         func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	SetBrightnessContextFunc               func(ctx context.Context, brightness int) (*arlo.EventStreamResponse, error)
+	EnableMotionAlertsContextFunc          func(ctx context.Context, sensitivity int, zones []string) (*arlo.EventStreamResponse, error)
+	DisableMotionAlertsContextFunc         func(ctx context.Context, sensitivity int, zones []string) (*arlo.EventStreamResponse, error)
+	EnableAudioAlertsContextFunc           func(ctx context.Context, sensitivity int) (*arlo.EventStreamResponse, error)
+	DisableAudioAlertsContextFunc          func(ctx context.Context, sensitivity int) (*arlo.EventStreamResponse, error)
+	PushToTalkContextFunc                  func(ctx context.Context) error
+	SetAlertNotificationMethodsContextFunc func(ctx context.Context, action string, email bool, push bool) (*arlo.EventStreamResponse, error)
+	StartStreamContextFunc                 func(ctx context.Context) (string, error)
+	StopStreamContextFunc                  func(ctx context.Context) error
+	TakeSnapshotContextFunc                func(ctx context.Context) (string, error)
+	TriggerFullFrameSnapshotContextFunc    func(ctx context.Context) (string, error)
+	DownloadFullFrameSnapshotContextFunc   func(ctx context.Context, w io.Writer) (string, error)
+	StartRecordingContextFunc              func(ctx context.Context) (string, error)
+	StopRecordingContextFunc               func(ctx context.Context) error
+	GetCvrPlaylistContextFunc              func(ctx context.Context, fromDate time.Time, toDate time.Time) (*arlo.CvrPlaylist, error)
+	SetVolumeContextFunc                   func(ctx context.Context, volume int) (*arlo.EventStreamResponse, error)
+	MuteContextFunc                        func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	UnMuteContextFunc                      func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	PlayContextFunc                        func(ctx context.Context, trackId string, position int) error
+	PauseContextFunc                       func(ctx context.Context) error
+	NextContextFunc                        func(ctx context.Context) error
+	ShuffleContextFunc                     func(ctx context.Context, on bool) (*arlo.EventStreamResponse, error)
+	ContinuousContextFunc                  func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	SingleTrackContextFunc                 func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	SetLoopBackModeContextFunc             func(ctx context.Context, loopbackMode string) (*arlo.EventStreamResponse, error)
+	GetAudioPlaybackContextFunc            func(ctx context.Context) (*arlo.AudioPlaybackState, error)
+	EnableSleepTimerContextFunc            func(ctx context.Context, sleepTime int64, sleepTimeRel int) (*arlo.EventStreamResponse, error)
+	DisableSleepTimerContextFunc           func(ctx context.Context, sleepTimeRel int) (*arlo.EventStreamResponse, error)
+	GetNightLightContextFunc               func(ctx context.Context) (*arlo.NightLightState, error)
+	NightLightContextFunc                  func(ctx context.Context, on bool) (*arlo.EventStreamResponse, error)
+	SetNightLightBrightnessContextFunc     func(ctx context.Context, level int) (*arlo.EventStreamResponse, error)
+	SetNightLightModeContextFunc           func(ctx context.Context, mode string) (*arlo.EventStreamResponse, error)
+	SetNightLightColorContextFunc          func(ctx context.Context, red int, blue int, green int) (*arlo.EventStreamResponse, error)
+	EnableNightLightTimerContextFunc       func(ctx context.Context, sleepTime int64, sleepTimeRel int) (*arlo.EventStreamResponse, error)
+	DisableNightLightTimerContextFunc      func(ctx context.Context, sleepTimeRel int) (*arlo.EventStreamResponse, error)
+}
+
+func (m *Camera) OnContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("OnContext", ctx)
+	if m.OnContextFunc == nil {
+		return nil, nil
+	}
+	return m.OnContextFunc(ctx)
+}
+
+func (m *Camera) OffContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("OffContext", ctx)
+	if m.OffContextFunc == nil {
+		return nil, nil
+	}
+	return m.OffContextFunc(ctx)
+}
+
+func (m *Camera) SetBrightnessContext(ctx context.Context, brightness int) (*arlo.EventStreamResponse, error) {
+	m.add("SetBrightnessContext", ctx, brightness)
+	if m.SetBrightnessContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetBrightnessContextFunc(ctx, brightness)
+}
+
+func (m *Camera) EnableMotionAlertsContext(ctx context.Context, sensitivity int, zones []string) (*arlo.EventStreamResponse, error) {
+	m.add("EnableMotionAlertsContext", ctx, sensitivity, zones)
+	if m.EnableMotionAlertsContextFunc == nil {
+		return nil, nil
+	}
+	return m.EnableMotionAlertsContextFunc(ctx, sensitivity, zones)
+}
+
+func (m *Camera) DisableMotionAlertsContext(ctx context.Context, sensitivity int, zones []string) (*arlo.EventStreamResponse, error) {
+	m.add("DisableMotionAlertsContext", ctx, sensitivity, zones)
+	if m.DisableMotionAlertsContextFunc == nil {
+		return nil, nil
+	}
+	return m.DisableMotionAlertsContextFunc(ctx, sensitivity, zones)
+}
+
+func (m *Camera) EnableAudioAlertsContext(ctx context.Context, sensitivity int) (*arlo.EventStreamResponse, error) {
+	m.add("EnableAudioAlertsContext", ctx, sensitivity)
+	if m.EnableAudioAlertsContextFunc == nil {
+		return nil, nil
+	}
+	return m.EnableAudioAlertsContextFunc(ctx, sensitivity)
+}
+
+func (m *Camera) DisableAudioAlertsContext(ctx context.Context, sensitivity int) (*arlo.EventStreamResponse, error) {
+	m.add("DisableAudioAlertsContext", ctx, sensitivity)
+	if m.DisableAudioAlertsContextFunc == nil {
+		return nil, nil
+	}
+	return m.DisableAudioAlertsContextFunc(ctx, sensitivity)
+}
+
+func (m *Camera) PushToTalkContext(ctx context.Context) error {
+	m.add("PushToTalkContext", ctx)
+	if m.PushToTalkContextFunc == nil {
+		return nil
+	}
+	return m.PushToTalkContextFunc(ctx)
+}
+
+func (m *Camera) SetAlertNotificationMethodsContext(ctx context.Context, action string, email bool, push bool) (*arlo.EventStreamResponse, error) {
+	m.add("SetAlertNotificationMethodsContext", ctx, action, email, push)
+	if m.SetAlertNotificationMethodsContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetAlertNotificationMethodsContextFunc(ctx, action, email, push)
+}
+
+func (m *Camera) StartStreamContext(ctx context.Context) (string, error) {
+	m.add("StartStreamContext", ctx)
+	if m.StartStreamContextFunc == nil {
+		return "", nil
+	}
+	return m.StartStreamContextFunc(ctx)
+}
+
+func (m *Camera) StopStreamContext(ctx context.Context) error {
+	m.add("StopStreamContext", ctx)
+	if m.StopStreamContextFunc == nil {
+		return nil
+	}
+	return m.StopStreamContextFunc(ctx)
+}
+
+func (m *Camera) TakeSnapshotContext(ctx context.Context) (string, error) {
+	m.add("TakeSnapshotContext", ctx)
+	if m.TakeSnapshotContextFunc == nil {
+		return "", nil
+	}
+	return m.TakeSnapshotContextFunc(ctx)
+}
+
+func (m *Camera) TriggerFullFrameSnapshotContext(ctx context.Context) (string, error) {
+	m.add("TriggerFullFrameSnapshotContext", ctx)
+	if m.TriggerFullFrameSnapshotContextFunc == nil {
+		return "", nil
+	}
+	return m.TriggerFullFrameSnapshotContextFunc(ctx)
+}
+
+func (m *Camera) DownloadFullFrameSnapshotContext(ctx context.Context, w io.Writer) (string, error) {
+	m.add("DownloadFullFrameSnapshotContext", ctx, w)
+	if m.DownloadFullFrameSnapshotContextFunc == nil {
+		return "", nil
+	}
+	return m.DownloadFullFrameSnapshotContextFunc(ctx, w)
+}
+
+func (m *Camera) StartRecordingContext(ctx context.Context) (string, error) {
+	m.add("StartRecordingContext", ctx)
+	if m.StartRecordingContextFunc == nil {
+		return "", nil
+	}
+	return m.StartRecordingContextFunc(ctx)
+}
+
+func (m *Camera) StopRecordingContext(ctx context.Context) error {
+	m.add("StopRecordingContext", ctx)
+	if m.StopRecordingContextFunc == nil {
+		return nil
+	}
+	return m.StopRecordingContextFunc(ctx)
+}
+
+func (m *Camera) GetCvrPlaylistContext(ctx context.Context, fromDate time.Time, toDate time.Time) (*arlo.CvrPlaylist, error) {
+	m.add("GetCvrPlaylistContext", ctx, fromDate, toDate)
+	if m.GetCvrPlaylistContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetCvrPlaylistContextFunc(ctx, fromDate, toDate)
+}
+
+func (m *Camera) SetVolumeContext(ctx context.Context, volume int) (*arlo.EventStreamResponse, error) {
+	m.add("SetVolumeContext", ctx, volume)
+	if m.SetVolumeContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetVolumeContextFunc(ctx, volume)
+}
+
+func (m *Camera) MuteContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("MuteContext", ctx)
+	if m.MuteContextFunc == nil {
+		return nil, nil
+	}
+	return m.MuteContextFunc(ctx)
+}
+
+func (m *Camera) UnMuteContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("UnMuteContext", ctx)
+	if m.UnMuteContextFunc == nil {
+		return nil, nil
+	}
+	return m.UnMuteContextFunc(ctx)
+}
+
+func (m *Camera) PlayContext(ctx context.Context, trackId string, position int) error {
+	m.add("PlayContext", ctx, trackId, position)
+	if m.PlayContextFunc == nil {
+		return nil
+	}
+	return m.PlayContextFunc(ctx, trackId, position)
+}
+
+func (m *Camera) PauseContext(ctx context.Context) error {
+	m.add("PauseContext", ctx)
+	if m.PauseContextFunc == nil {
+		return nil
+	}
+	return m.PauseContextFunc(ctx)
+}
+
+func (m *Camera) NextContext(ctx context.Context) error {
+	m.add("NextContext", ctx)
+	if m.NextContextFunc == nil {
+		return nil
+	}
+	return m.NextContextFunc(ctx)
+}
+
+func (m *Camera) ShuffleContext(ctx context.Context, on bool) (*arlo.EventStreamResponse, error) {
+	m.add("ShuffleContext", ctx, on)
+	if m.ShuffleContextFunc == nil {
+		return nil, nil
+	}
+	return m.ShuffleContextFunc(ctx, on)
+}
+
+func (m *Camera) ContinuousContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("ContinuousContext", ctx)
+	if m.ContinuousContextFunc == nil {
+		return nil, nil
+	}
+	return m.ContinuousContextFunc(ctx)
+}
+
+func (m *Camera) SingleTrackContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("SingleTrackContext", ctx)
+	if m.SingleTrackContextFunc == nil {
+		return nil, nil
+	}
+	return m.SingleTrackContextFunc(ctx)
+}
+
+func (m *Camera) SetLoopBackModeContext(ctx context.Context, loopbackMode string) (*arlo.EventStreamResponse, error) {
+	m.add("SetLoopBackModeContext", ctx, loopbackMode)
+	if m.SetLoopBackModeContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetLoopBackModeContextFunc(ctx, loopbackMode)
+}
+
+func (m *Camera) GetAudioPlaybackContext(ctx context.Context) (*arlo.AudioPlaybackState, error) {
+	m.add("GetAudioPlaybackContext", ctx)
+	if m.GetAudioPlaybackContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetAudioPlaybackContextFunc(ctx)
+}
+
+func (m *Camera) EnableSleepTimerContext(ctx context.Context, sleepTime int64, sleepTimeRel int) (*arlo.EventStreamResponse, error) {
+	m.add("EnableSleepTimerContext", ctx, sleepTime, sleepTimeRel)
+	if m.EnableSleepTimerContextFunc == nil {
+		return nil, nil
+	}
+	return m.EnableSleepTimerContextFunc(ctx, sleepTime, sleepTimeRel)
+}
+
+func (m *Camera) DisableSleepTimerContext(ctx context.Context, sleepTimeRel int) (*arlo.EventStreamResponse, error) {
+	m.add("DisableSleepTimerContext", ctx, sleepTimeRel)
+	if m.DisableSleepTimerContextFunc == nil {
+		return nil, nil
+	}
+	return m.DisableSleepTimerContextFunc(ctx, sleepTimeRel)
+}
+
+func (m *Camera) GetNightLightContext(ctx context.Context) (*arlo.NightLightState, error) {
+	m.add("GetNightLightContext", ctx)
+	if m.GetNightLightContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetNightLightContextFunc(ctx)
+}
+
+func (m *Camera) NightLightContext(ctx context.Context, on bool) (*arlo.EventStreamResponse, error) {
+	m.add("NightLightContext", ctx, on)
+	if m.NightLightContextFunc == nil {
+		return nil, nil
+	}
+	return m.NightLightContextFunc(ctx, on)
+}
+
+func (m *Camera) SetNightLightBrightnessContext(ctx context.Context, level int) (*arlo.EventStreamResponse, error) {
+	m.add("SetNightLightBrightnessContext", ctx, level)
+	if m.SetNightLightBrightnessContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetNightLightBrightnessContextFunc(ctx, level)
+}
+
+func (m *Camera) SetNightLightModeContext(ctx context.Context, mode string) (*arlo.EventStreamResponse, error) {
+	m.add("SetNightLightModeContext", ctx, mode)
+	if m.SetNightLightModeContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetNightLightModeContextFunc(ctx, mode)
+}
+
+func (m *Camera) SetNightLightColorContext(ctx context.Context, red int, blue int, green int) (*arlo.EventStreamResponse, error) {
+	m.add("SetNightLightColorContext", ctx, red, blue, green)
+	if m.SetNightLightColorContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetNightLightColorContextFunc(ctx, red, blue, green)
+}
+
+func (m *Camera) EnableNightLightTimerContext(ctx context.Context, sleepTime int64, sleepTimeRel int) (*arlo.EventStreamResponse, error) {
+	m.add("EnableNightLightTimerContext", ctx, sleepTime, sleepTimeRel)
+	if m.EnableNightLightTimerContextFunc == nil {
+		return nil, nil
+	}
+	return m.EnableNightLightTimerContextFunc(ctx, sleepTime, sleepTimeRel)
+}
+
+func (m *Camera) DisableNightLightTimerContext(ctx context.Context, sleepTimeRel int) (*arlo.EventStreamResponse, error) {
+	m.add("DisableNightLightTimerContext", ctx, sleepTimeRel)
+	if m.DisableNightLightTimerContextFunc == nil {
+		return nil, nil
+	}
+	return m.DisableNightLightTimerContextFunc(ctx, sleepTimeRel)
+}
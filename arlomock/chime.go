@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlomock
+
+import (
+	"context"
+
+	arlo "github.com/jeffreydwalter/arlo-go"
+)
+
+var (
+	_ arlo.ChimeController = (*Chime)(nil)
+)
+
+// Chime is a mock of arlo.ChimeController.
+// Each method calls the func field of the same name, or returns zero values if it's nil.
+type Chime struct {
+	Recorder
+
+	GetStateContextFunc func(ctx context.Context) (*arlo.EventStreamResponse, error)
+}
+
+func (m *Chime) GetStateContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("GetStateContext", ctx)
+	if m.GetStateContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetStateContextFunc(ctx)
+}
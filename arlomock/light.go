@@ -0,0 +1,115 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlomock
+
+import (
+	"context"
+
+	arlo "github.com/jeffreydwalter/arlo-go"
+)
+
+var (
+	_ arlo.LightController = (*Light)(nil)
+)
+
+// Light is a mock of arlo.LightController.
+// Each method calls the func field of the same name, or returns zero values if it's nil.
+type Light struct {
+	Recorder
+
+	GetStateContextFunc            func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	OnContextFunc                  func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	OffContextFunc                 func(ctx context.Context) (*arlo.EventStreamResponse, error)
+	SetBrightnessContextFunc       func(ctx context.Context, brightness int) (*arlo.EventStreamResponse, error)
+	SetColorTemperatureContextFunc func(ctx context.Context, kelvin int) (*arlo.EventStreamResponse, error)
+	SetColorContextFunc            func(ctx context.Context, red int, green int, blue int) (*arlo.EventStreamResponse, error)
+	SetDurationContextFunc         func(ctx context.Context, seconds int) (*arlo.EventStreamResponse, error)
+	SetMotionActivationContextFunc func(ctx context.Context, enabled bool, sensitivity int, duration int) (*arlo.EventStreamResponse, error)
+	SetFlashContextFunc            func(ctx context.Context, pattern string) (*arlo.EventStreamResponse, error)
+}
+
+func (m *Light) GetStateContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("GetStateContext", ctx)
+	if m.GetStateContextFunc == nil {
+		return nil, nil
+	}
+	return m.GetStateContextFunc(ctx)
+}
+
+func (m *Light) OnContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("OnContext", ctx)
+	if m.OnContextFunc == nil {
+		return nil, nil
+	}
+	return m.OnContextFunc(ctx)
+}
+
+func (m *Light) OffContext(ctx context.Context) (*arlo.EventStreamResponse, error) {
+	m.add("OffContext", ctx)
+	if m.OffContextFunc == nil {
+		return nil, nil
+	}
+	return m.OffContextFunc(ctx)
+}
+
+func (m *Light) SetBrightnessContext(ctx context.Context, brightness int) (*arlo.EventStreamResponse, error) {
+	m.add("SetBrightnessContext", ctx, brightness)
+	if m.SetBrightnessContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetBrightnessContextFunc(ctx, brightness)
+}
+
+func (m *Light) SetColorTemperatureContext(ctx context.Context, kelvin int) (*arlo.EventStreamResponse, error) {
+	m.add("SetColorTemperatureContext", ctx, kelvin)
+	if m.SetColorTemperatureContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetColorTemperatureContextFunc(ctx, kelvin)
+}
+
+func (m *Light) SetColorContext(ctx context.Context, red int, green int, blue int) (*arlo.EventStreamResponse, error) {
+	m.add("SetColorContext", ctx, red, green, blue)
+	if m.SetColorContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetColorContextFunc(ctx, red, green, blue)
+}
+
+func (m *Light) SetDurationContext(ctx context.Context, seconds int) (*arlo.EventStreamResponse, error) {
+	m.add("SetDurationContext", ctx, seconds)
+	if m.SetDurationContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetDurationContextFunc(ctx, seconds)
+}
+
+func (m *Light) SetMotionActivationContext(ctx context.Context, enabled bool, sensitivity int, duration int) (*arlo.EventStreamResponse, error) {
+	m.add("SetMotionActivationContext", ctx, enabled, sensitivity, duration)
+	if m.SetMotionActivationContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetMotionActivationContextFunc(ctx, enabled, sensitivity, duration)
+}
+
+func (m *Light) SetFlashContext(ctx context.Context, pattern string) (*arlo.EventStreamResponse, error) {
+	m.add("SetFlashContext", ctx, pattern)
+	if m.SetFlashContextFunc == nil {
+		return nil, nil
+	}
+	return m.SetFlashContextFunc(ctx, pattern)
+}
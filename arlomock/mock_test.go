@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlomock_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	arlo "github.com/jeffreydwalter/arlo-go"
+	"github.com/jeffreydwalter/arlo-go/arlomock"
+)
+
+// armAll arms every basestation, like code that's tested with the mocks would.
+func armAll(ctx context.Context, basestations []arlo.BasestationController) error {
+	for _, b := range basestations {
+		if _, err := b.ArmContext(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestFuncFields(t *testing.T) {
+	errArm := errors.New("arm failed")
+	armed := &arlomock.Basestation{
+		ArmContextFunc: func(ctx context.Context) (*arlo.EventStreamResponse, error) {
+			return &arlo.EventStreamResponse{}, nil
+		},
+	}
+	failing := &arlomock.Basestation{
+		ArmContextFunc: func(ctx context.Context) (*arlo.EventStreamResponse, error) {
+			return nil, errArm
+		},
+	}
+
+	if err := armAll(context.Background(), []arlo.BasestationController{armed, failing}); err != errArm {
+		t.Errorf("armAll() = %v, want %v", err, errArm)
+	}
+	if armed.CallCount("ArmContext") != 1 || failing.CallCount("ArmContext") != 1 {
+		t.Errorf("ArmContext calls = %d, %d, want 1, 1", armed.CallCount("ArmContext"), failing.CallCount("ArmContext"))
+	}
+
+	var c arlo.CameraController = &arlomock.Camera{
+		StartStreamContextFunc: func(ctx context.Context) (string, error) {
+			return "rtsps://stream", nil
+		},
+	}
+	if url, err := c.StartStreamContext(context.Background()); url != "rtsps://stream" || err != nil {
+		t.Errorf("StartStreamContext() = %q, %v, want the url from the func field", url, err)
+	}
+
+	// A method without a func field returns zero values.
+	var l arlo.LightController = &arlomock.Light{}
+	if response, err := l.OnContext(context.Background()); response != nil || err != nil {
+		t.Errorf("OnContext() = %v, %v, want nil, nil", response, err)
+	}
+	var s arlo.DeviceService = &arlomock.Arlo{}
+	if devices, err := s.GetDevicesContext(context.Background()); devices != nil || err != nil {
+		t.Errorf("GetDevicesContext() = %v, %v, want nil, nil", devices, err)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	l := &arlomock.Light{}
+	l.SetBrightnessContext(ctx, 50)
+	l.SetColorContext(ctx, 1, 2, 3)
+	l.SetBrightnessContext(ctx, 75)
+
+	calls := l.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("got %d calls, want 3", len(calls))
+	}
+	if calls[1].Method != "SetColorContext" || len(calls[1].Args) != 4 || calls[1].Args[0] != ctx || calls[1].Args[3] != 3 {
+		t.Errorf("calls[1] = %+v, want SetColorContext with ctx, 1, 2, 3", calls[1])
+	}
+	if calls[2].Method != "SetBrightnessContext" || calls[2].Args[1] != 75 {
+		t.Errorf("calls[2] = %+v, want SetBrightnessContext with 75", calls[2])
+	}
+	if n := l.CallCount("SetBrightnessContext"); n != 2 {
+		t.Errorf("CallCount(SetBrightnessContext) = %d, want 2", n)
+	}
+
+	// The calls are a copy.
+	calls[0].Method = "changed"
+	if l.Calls()[0].Method != "SetBrightnessContext" {
+		t.Error("changing the result of Calls() changed the recorded calls")
+	}
+
+	l.Reset()
+	if len(l.Calls()) != 0 || l.CallCount("SetBrightnessContext") != 0 {
+		t.Errorf("Calls() after Reset() = %v, want none", l.Calls())
+	}
+
+	// The mocks can be called from many goroutines at once.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.OffContext(ctx)
+		}()
+	}
+	wg.Wait()
+	if n := l.CallCount("OffContext"); n != 10 {
+		t.Errorf("CallCount(OffContext) = %d, want 10", n)
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+// An ArloQ is a camera that connects to the cloud on its own, without a basestation, so it's its own basestation.
+// It has the operations of both a Basestation and a Camera.
+type ArloQ struct {
+	Basestation
+	Camera
+}
+
+// ArloQs is a slice of ArloQ objects.
+type ArloQs []ArloQ
+
+func newArloQ(d Device) *ArloQ {
+	return &ArloQ{Basestation: Basestation{Device: d}, Camera: Camera(d)}
+}
+
+// Find returns an Arlo Q with the device id passed in.
+func (qs *ArloQs) Find(deviceId string) *ArloQ {
+	for i := range *qs {
+		if (*qs)[i].Camera.DeviceId == deviceId {
+			return &(*qs)[i]
+		}
+	}
+
+	return nil
+}
+
+// ArloQCollection is the Arlo Qs of the account. It's a view of the devices, like DeviceCollection.
+type ArloQCollection struct {
+	view
+}
+
+var arloQKinds = []DeviceKind{KindArloQ}
+
+// All returns the Arlo Qs, in the order the Arlo API returned them.
+func (qs ArloQCollection) All() []*ArloQ {
+	return arloQs(qs.list(nil))
+}
+
+// Range calls fn for each Arlo Q, in order, until it returns false. See DeviceCollection.Range.
+func (qs ArloQCollection) Range(fn func(q *ArloQ) bool) {
+	for _, q := range qs.All() {
+		if !fn(q) {
+			return
+		}
+	}
+}
+
+// Find returns the Arlo Q with the device id, or nil if there isn't one.
+func (qs ArloQCollection) Find(deviceId string) *ArloQ {
+	q, _ := qs.findId(deviceId).(*ArloQ)
+	return q
+}
+
+// FindByName returns the first Arlo Q with the name, or nil if there isn't one.
+func (qs ArloQCollection) FindByName(name string) *ArloQ {
+	q, _ := qs.find(byName(name)).(*ArloQ)
+	return q
+}
+
+// FindByUniqueId returns the Arlo Q with the unique id, or nil if there isn't one.
+func (qs ArloQCollection) FindByUniqueId(uniqueId string) *ArloQ {
+	q, _ := qs.find(byUniqueId(uniqueId)).(*ArloQ)
+	return q
+}
+
+// FindByModel returns the Arlo Qs of the model.
+func (qs ArloQCollection) FindByModel(modelId string) []*ArloQ {
+	return arloQs(qs.list(byModel(modelId)))
+}
+
+func arloQs(vs []interface{}) []*ArloQ {
+	var arloQs []*ArloQ
+	for _, q := range vs {
+		arloQs = append(arloQs, q.(*ArloQ))
+	}
+	return arloQs
+}
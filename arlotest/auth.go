@@ -0,0 +1,218 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlotest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// The fake of the authentication api (ocapi), which is served by the same Server. Point an arlo client at it with
+// arlo.WithAuthURL(srv.URL).
+
+const (
+	// trustCookie is set for a client that asked to be trusted when it finished two-factor authentication, so it can
+	// skip the second factor the next time it logs in.
+	trustCookie = "browser_trust_token"
+	// factorAuthCode is what the code that was requested with startAuth is submitted with.
+	factorAuthCode = "FACTOR-AUTH-CODE"
+)
+
+// A Factor is a second factor of the account, which a two-factor authentication code is sent to.
+type Factor struct {
+	FactorId    string `json:"factorId"`
+	FactorType  string `json:"factorType"`
+	FactorRole  string `json:"factorRole"`
+	DisplayName string `json:"displayName"`
+}
+
+// EnableTwoFactor makes logging in through the authentication api need a second factor. The code is the one that's
+// "sent" to each of the factors.
+func (s *Server) EnableTwoFactor(code string, factors ...Factor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.code = code
+	s.factors = factors
+}
+
+// serveAuth serves the routes of the authentication api, and returns false for any other route.
+func (s *Server) serveAuth(w http.ResponseWriter, r *http.Request, body []byte) bool {
+	switch r.Method + " " + r.URL.Path {
+	case "POST /auth":
+		s.auth(w, r, body)
+	case "GET /getFactors":
+		if s.authToken(w, r) {
+			s.mutex.Lock()
+			factors := append([]Factor{}, s.factors...)
+			s.mutex.Unlock()
+			authSuccess(w, map[string]interface{}{"items": factors})
+		}
+	case "POST /startAuth":
+		if s.authToken(w, r) {
+			s.startAuth(w, body)
+		}
+	case "POST /finishAuth":
+		if s.authToken(w, r) {
+			s.finishAuth(w, body)
+		}
+	case "GET /validateAccessToken":
+		if !s.authorized(decodeToken(r)) {
+			authError(w, http.StatusUnauthorized, 2015, "Invalid access token.")
+			return true
+		}
+		authSuccess(w, nil)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *Server) auth(w http.ResponseWriter, r *http.Request, body []byte) {
+	var credentials struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	json.Unmarshal(body, &credentials)
+	password, _ := base64.StdEncoding.DecodeString(credentials.Password)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if credentials.Email != s.User || string(password) != s.Password {
+		authError(w, http.StatusUnauthorized, 2002, "Invalid email or password.")
+		return
+	}
+
+	trusted := false
+	if c, err := r.Cookie(trustCookie); err == nil && s.trusted[c.Value] {
+		trusted = true
+	}
+	mfa := len(s.factors) > 0 && !trusted
+
+	// Until the second factor is supplied, the token only works with the authentication api.
+	token := s.newToken()
+	if mfa {
+		s.pending = token
+	} else {
+		s.token, s.pending = token, ""
+	}
+
+	authSuccess(w, s.authTokenData(token, mfa))
+}
+
+func (s *Server) startAuth(w http.ResponseWriter, body []byte) {
+	var request struct {
+		FactorId string `json:"factorId"`
+	}
+	json.Unmarshal(body, &request)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, f := range s.factors {
+		if f.FactorId == request.FactorId {
+			authSuccess(w, map[string]string{"factorAuthCode": factorAuthCode})
+			return
+		}
+	}
+	authError(w, http.StatusBadRequest, 9204, "Unknown factor.")
+}
+
+func (s *Server) finishAuth(w http.ResponseWriter, body []byte) {
+	var request struct {
+		FactorAuthCode   string `json:"factorAuthCode"`
+		OTP              string `json:"otp"`
+		IsBrowserTrusted bool   `json:"isBrowserTrusted"`
+	}
+	json.Unmarshal(body, &request)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if request.FactorAuthCode != factorAuthCode || request.OTP != s.code {
+		authError(w, http.StatusBadRequest, 4000, "Invalid code.")
+		return
+	}
+
+	if request.IsBrowserTrusted {
+		value := fmt.Sprintf("trusted-%d", len(s.trusted)+1)
+		if s.trusted == nil {
+			s.trusted = make(map[string]bool)
+		}
+		s.trusted[value] = true
+		http.SetCookie(w, &http.Cookie{Name: trustCookie, Value: value, Path: "/"})
+	}
+
+	token := s.newToken()
+	s.token, s.pending = token, ""
+	authSuccess(w, s.authTokenData(token, false))
+}
+
+// authToken checks the token that the authentication api is called with, which is base64 encoded, and answers with an
+// error if it isn't the token that the last login got.
+func (s *Server) authToken(w http.ResponseWriter, r *http.Request) bool {
+	token := decodeToken(r)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if token == "" || (token != s.pending && token != s.token) {
+		authError(w, http.StatusUnauthorized, 2015, "Invalid access token.")
+		return false
+	}
+	return true
+}
+
+func decodeToken(r *http.Request) string {
+	b, err := base64.StdEncoding.DecodeString(r.Header.Get("Authorization"))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// newToken makes a new auth token. s.mutex must be held.
+func (s *Server) newToken() string {
+	s.tokens++
+	return fmt.Sprintf("token-%d", s.tokens)
+}
+
+func (s *Server) authTokenData(token string, mfa bool) map[string]interface{} {
+	now := time.Now()
+	return map[string]interface{}{
+		"token":         token,
+		"userId":        s.UserId,
+		"authenticated": now.Unix(),
+		"issued":        now.Unix(),
+		"expiresIn":     now.Add(24 * time.Hour).Unix(),
+		"mfa":           mfa,
+		"authCompleted": !mfa,
+	}
+}
+
+// authSuccess writes a successful response in the format used by the authentication api.
+func authSuccess(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"meta": map[string]interface{}{"code": http.StatusOK}, "data": data})
+}
+
+// authError writes a failed response in the format used by the authentication api.
+func authError(w http.ResponseWriter, statusCode int, code int, message string) {
+	writeJSON(w, statusCode, map[string]interface{}{"meta": map[string]interface{}{"code": statusCode, "error": code, "message": message}})
+}
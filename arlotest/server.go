@@ -0,0 +1,751 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package arlotest provides an in-process fake of the Arlo cloud api (hmsweb), for testing code that uses arlo-go
+// without talking to my.arlo.com.
+//
+// The fake handles logging in and out, including two-factor authentication through the authentication api, the session,
+// the devices, the library, and the event stream: notify requests are answered on the event stream with a response that
+// has the same transId, and unsolicited events can be published to it at any time.
+//
+//	srv := arlotest.NewServer()
+//	defer srv.Close()
+//
+//	srv.AddDevice(arlotest.Basestation("BASESTATION1"))
+//	srv.AddDevice(arlotest.Camera("CAMERA1", "BASESTATION1"))
+//
+//	a, _ := arlo.NewClient(arlo.WithBaseURL(srv.URL))
+//	err := a.Login(arlotest.DefaultUser, arlotest.DefaultPassword)
+//
+// This package doesn't import arlo, so it can be used by the tests of package arlo itself.
+package arlotest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultUser     = "user@example.com"
+	DefaultPassword = "password"
+	DefaultUserId   = "XXX-1234567"
+)
+
+const (
+	notifyPath       = "/users/devices/notify/"
+	capabilitiesPath = "/resources/capabilities/en/en_"
+)
+
+// A Device is the device data returned by the devices endpoint.
+type Device struct {
+	DeviceId              string                 `json:"deviceId"`
+	DeviceName            string                 `json:"deviceName"`
+	DeviceType            string                 `json:"deviceType"`
+	ModelId               string                 `json:"modelId"`
+	UniqueId              string                 `json:"uniqueId"`
+	ParentId              string                 `json:"parentId"`
+	UserId                string                 `json:"userId"`
+	XCloudId              string                 `json:"xCloudId"`
+	State                 string                 `json:"state"`
+	FirmwareVersion       string                 `json:"firmwareVersion"`
+	PresignedLastImageUrl string                 `json:"presignedLastImageUrl,omitempty"` // Changes every time the devices are fetched.
+	Connectivity          map[string]interface{} `json:"connectivity,omitempty"`
+	Properties            map[string]interface{} `json:"properties,omitempty"`
+}
+
+// Basestation returns a basestation with the device id, ready to be added to a Server.
+func Basestation(deviceId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "basestation",
+		ModelId:         "VMB4000",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        deviceId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + deviceId,
+		State:           "provisioned",
+		FirmwareVersion: "1.12.2.3_2337",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wired"},
+	}
+}
+
+// Camera returns a camera with the device id, connected to the basestation with the parent id, ready to be added to a Server.
+func Camera(deviceId string, parentId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "camera",
+		ModelId:         "VMC4030",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        parentId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + parentId,
+		State:           "provisioned",
+		FirmwareVersion: "1.125.13.0_1140",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wifi"},
+	}
+}
+
+// Light returns a light with the device id, connected to the basestation with the parent id, ready to be added to a Server.
+func Light(deviceId string, parentId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "lights",
+		ModelId:         "ABL1000",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        parentId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + parentId,
+		State:           "provisioned",
+		FirmwareVersion: "1.0.3.0_1044",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wifi"},
+	}
+}
+
+// Siren returns a siren with the device id, connected to the basestation with the parent id, ready to be added to a Server.
+func Siren(deviceId string, parentId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "siren",
+		ModelId:         "VMA4100",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        parentId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + parentId,
+		State:           "provisioned",
+		FirmwareVersion: "1.2.0.6_105",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wifi"},
+	}
+}
+
+// ArloQ returns an Arlo Q with the device id, which is its own basestation, ready to be added to a Server.
+func ArloQ(deviceId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "arloq",
+		ModelId:         "VMC3040",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        deviceId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + deviceId,
+		State:           "provisioned",
+		FirmwareVersion: "1.9.6.0_17838",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wifi"},
+	}
+}
+
+// Bridge returns an Arlo Bridge with the device id, ready to be added to a Server.
+func Bridge(deviceId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "arlobridge",
+		ModelId:         "ABB1000",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        deviceId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + deviceId,
+		State:           "provisioned",
+		FirmwareVersion: "1.2.1.3_1040",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wifi"},
+	}
+}
+
+// Doorbell returns an audio doorbell with the device id, connected to the basestation with the parent id, ready to be added
+// to a Server.
+func Doorbell(deviceId string, parentId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "doorbell",
+		ModelId:         "AAD1001",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        parentId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + parentId,
+		State:           "provisioned",
+		FirmwareVersion: "1.2.0.5_1020",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wifi"},
+	}
+}
+
+// Chime returns a chime with the device id, connected to the basestation with the parent id, ready to be added to a Server.
+func Chime(deviceId string, parentId string) Device {
+	return Device{
+		DeviceId:        deviceId,
+		DeviceName:      deviceId,
+		DeviceType:      "chime",
+		ModelId:         "AC1001",
+		UniqueId:        DefaultUserId + "_" + deviceId,
+		ParentId:        parentId,
+		UserId:          DefaultUserId,
+		XCloudId:        "XCLOUD-" + parentId,
+		State:           "provisioned",
+		FirmwareVersion: "1.0.1.3_1010",
+		Connectivity:    map[string]interface{}{"connected": true, "type": "wifi"},
+	}
+}
+
+// A Recording is a video in the library.
+type Recording struct {
+	MediaDurationSecond   int    `json:"mediaDurationSecond"`
+	ContentType           string `json:"contentType"`
+	Name                  string `json:"name"`
+	PresignedContentUrl   string `json:"presignedContentUrl"`
+	LastModified          int64  `json:"lastModified"`
+	LocalCreatedDate      int64  `json:"localCreatedDate"`
+	PresignedThumbnailUrl string `json:"presignedThumbnailUrl"`
+	Reason                string `json:"reason"`
+	DeviceId              string `json:"deviceId"`
+	CreatedBy             string `json:"createdBy"`
+	CreatedDate           string `json:"createdDate"`
+	TimeZone              string `json:"timeZone"`
+	OwnerId               string `json:"ownerId"`
+	UtcCreatedDate        int64  `json:"utcCreatedDate"`
+	CurrentState          string `json:"currentState"`
+	MediaDuration         string `json:"mediaDuration"`
+	UniqueId              string `json:"uniqueId"`
+}
+
+// A Message is a message on the event stream. It's both what's sent to the notify endpoint, and what comes back.
+type Message struct {
+	Action          string      `json:"action,omitempty"`
+	Resource        string      `json:"resource,omitempty"`
+	PublishResponse bool        `json:"publishResponse"`
+	Properties      interface{} `json:"properties,omitempty"`
+	TransId         string      `json:"transId"`
+	From            string      `json:"from"`
+	To              string      `json:"to"`
+	DeviceId        string      `json:"deviceId,omitempty"`
+	Status          string      `json:"status,omitempty"`
+	// PresignedContentUrl is the url of the snapshot in a mediaUploadNotification.
+	PresignedContentUrl string `json:"presignedContentUrl,omitempty"`
+}
+
+// A Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   []byte
+}
+
+// A Responder answers a notify request with the message to publish on the event stream.
+// deviceId is the basestation the request was sent to. If ok is false, nothing is published.
+type Responder func(deviceId string, msg Message) (response Message, ok bool)
+
+// A Server is a fake Arlo cloud api. Use its URL as the base url of the Arlo client.
+type Server struct {
+	*httptest.Server
+
+	User     string
+	Password string
+	UserId   string
+
+	mutex      sync.Mutex
+	token      string
+	tokens     int
+	expired    int             // The status that a rejected token is answered with.
+	pending    string          // The token of a login that's waiting for its second factor.
+	code       string          // The two-factor authentication code.
+	factors    []Factor        // Two-factor authentication is enabled when there are any.
+	trusted    map[string]bool // The trust cookies of the trusted clients.
+	devices    []Device
+	recordings []Recording
+	models     map[string]interface{}            // Capabilities by modelId.
+	state      map[string]map[string]interface{} // Properties by device id and resource.
+	handlers   map[string]http.HandlerFunc
+	responder  Responder
+	requests   []Request
+	notified   []Message
+	streams    map[*stream]struct{}
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewServer starts a fake Arlo cloud api with an account for DefaultUser and DefaultPassword, and no devices.
+// Call Close when you're done with it.
+func NewServer() *Server {
+	s := &Server{
+		User:     DefaultUser,
+		Password: DefaultPassword,
+		UserId:   DefaultUserId,
+		models:   make(map[string]interface{}),
+		state:    make(map[string]map[string]interface{}),
+		handlers: make(map[string]http.HandlerFunc),
+		streams:  make(map[*stream]struct{}),
+		done:     make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close disconnects the event streams and shuts down the server.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	s.Server.Close()
+}
+
+// Token returns the auth token that the server currently accepts, or "" if nobody has logged in.
+func (s *Server) Token() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.token
+}
+
+// ExpireToken makes the server reject the current auth token with 401 Unauthorized, like it does when a token expires.
+// Logging in again gets a new token.
+func (s *Server) ExpireToken() {
+	s.ExpireTokenWithStatus(http.StatusUnauthorized)
+}
+
+// ExpireTokenWithStatus is like ExpireToken, but answers with the status instead, e.g. 200 OK, which the Arlo API also
+// reports an expired session with, with only the error code in the body saying what's wrong.
+func (s *Server) ExpireTokenWithStatus(statusCode int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.token = ""
+	s.expired = statusCode
+}
+
+// AddDevice adds a device to the account.
+func (s *Server) AddDevice(d Device) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.devices = append(s.devices, d)
+}
+
+// RemoveDevice removes the device with the device id from the account.
+func (s *Server) RemoveDevice(deviceId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range s.devices {
+		if s.devices[i].DeviceId == deviceId {
+			s.devices = append(s.devices[:i], s.devices[i+1:]...)
+			return
+		}
+	}
+}
+
+// AddRecording adds a video to the library.
+func (s *Server) AddRecording(r Recording) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.recordings = append(s.recordings, r)
+}
+
+// Recordings returns the videos in the library.
+func (s *Server) Recordings() []Recording {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Recording(nil), s.recordings...)
+}
+
+// SetCapabilities sets the capabilities document of the model. A model without one gets a 404 Not Found.
+func (s *Server) SetCapabilities(modelId string, capabilities interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.models[modelId] = capabilities
+}
+
+// SetState sets the properties that a "get" of the resource, sent to the device, is answered with.
+// A "set" of the resource updates them.
+func (s *Server) SetState(deviceId string, resource string, properties interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.state[deviceId] == nil {
+		s.state[deviceId] = make(map[string]interface{})
+	}
+	s.state[deviceId][resource] = properties
+}
+
+// State returns the properties of the resource of the device.
+func (s *Server) State(deviceId string, resource string) interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.state[deviceId][resource]
+}
+
+// Respond replaces how notify requests are answered. A nil responder restores the default, which is described by
+// DefaultResponse.
+func (s *Server) Respond(responder Responder) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responder = responder
+}
+
+// Handle replaces the handler of the method and path (without the query), or adds one for an endpoint the server
+// doesn't have.
+func (s *Server) Handle(method string, path string, handler http.HandlerFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[method+" "+path] = handler
+}
+
+// Requests returns every request the server has received, in order.
+func (s *Server) Requests() []Request {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Notifications returns every message that was sent to the notify endpoint, in order.
+func (s *Server) Notifications() []Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Message(nil), s.notified...)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+
+	s.mutex.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery, Header: r.Header.Clone(), Body: body})
+	handler, ok := s.handlers[r.Method+" "+r.URL.Path]
+	s.mutex.Unlock()
+
+	if ok {
+		r.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+		handler(w, r)
+		return
+	}
+
+	if s.serveAuth(w, r, body) {
+		return
+	}
+
+	route := r.Method + " " + r.URL.Path
+	if route == "POST /login/v2" {
+		s.login(w, body)
+		return
+	}
+
+	// The capabilities are a static resource, which doesn't need the token.
+	if r.Method == "GET" && strings.HasPrefix(r.URL.Path, capabilitiesPath) {
+		s.capabilities(w, r.URL.Path)
+		return
+	}
+
+	// Everything else needs the token, which the event stream has in the query rather than a header.
+	token := r.Header.Get("Authorization")
+	if r.URL.Path == "/client/subscribe" {
+		token = r.URL.Query().Get("token")
+	}
+	if !s.authorized(token) {
+		s.mutex.Lock()
+		status := s.expired
+		s.mutex.Unlock()
+		if status == 0 {
+			status = http.StatusUnauthorized
+		}
+		Error(w, status, "2015", "Your session has expired. Please log in again.")
+		return
+	}
+
+	switch {
+	case route == "GET /users/session":
+		s.session(w)
+	case route == "PUT /logout":
+		s.logout(w)
+	case route == "GET /users/devices/":
+		s.getDevices(w)
+	case route == "POST /users/library/metadata/v2":
+		s.libraryMetaData(w, body)
+	case route == "POST /users/library":
+		s.library(w, body)
+	case route == "POST /users/library/recycle":
+		s.recycle(w, body)
+	case route == "GET /client/subscribe":
+		s.subscribe(w, r)
+	case route == "GET /client/unsubscribe":
+		Success(w, nil)
+	case r.Method == "POST" && strings.HasPrefix(r.URL.Path, notifyPath):
+		s.notify(w, strings.TrimPrefix(r.URL.Path, notifyPath), body)
+	default:
+		Error(w, http.StatusNotFound, "404", "not found: "+route)
+	}
+}
+
+// capabilities serves the capabilities document of a model, at e.g. /resources/capabilities/en/en_VMC4030|i1000.json.
+func (s *Server) capabilities(w http.ResponseWriter, path string) {
+	modelId := strings.TrimPrefix(path, capabilitiesPath)
+	if i := strings.Index(modelId, "|"); i >= 0 {
+		modelId = modelId[:i]
+	}
+
+	s.mutex.Lock()
+	capabilities, ok := s.models[modelId]
+	s.mutex.Unlock()
+
+	if !ok {
+		Error(w, http.StatusNotFound, "404", "no capabilities for model "+modelId)
+		return
+	}
+	writeJSON(w, http.StatusOK, capabilities)
+}
+
+func (s *Server) authorized(token string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return token != "" && token == s.token
+}
+
+// Success writes a successful response with data, in the format used by the Arlo api.
+func Success(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data, "success": true})
+}
+
+// Error writes a failed response, in the format used by the Arlo api.
+func Error(w http.ResponseWriter, statusCode int, code string, reason string) {
+	writeJSON(w, statusCode, map[string]interface{}{"data": map[string]string{"error": code, "reason": reason}, "success": false})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) account() map[string]interface{} {
+	return map[string]interface{}{
+		"userId":        s.UserId,
+		"email":         s.User,
+		"token":         s.token,
+		"paymentId":     "12345678",
+		"authenticated": time.Now().Unix(),
+		"accountStatus": "registered",
+		"serialNumber":  "48B14C1299999",
+		"countryCode":   "US",
+		"validEmail":    true,
+		"arlo":          true,
+		"dateCreated":   1463975008658,
+	}
+}
+
+func (s *Server) login(w http.ResponseWriter, body []byte) {
+	var credentials struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	json.Unmarshal(body, &credentials)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if credentials.Email != s.User || credentials.Password != s.Password {
+		Error(w, http.StatusUnauthorized, "2002", "Invalid email or password.")
+		return
+	}
+
+	s.token = s.newToken()
+
+	Success(w, s.account())
+}
+
+func (s *Server) session(w http.ResponseWriter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	Success(w, s.account())
+}
+
+func (s *Server) logout(w http.ResponseWriter) {
+	s.mutex.Lock()
+	s.token = ""
+	s.mutex.Unlock()
+
+	// Logging out closes the event streams.
+	s.Publish(Message{Action: "logout"})
+	Success(w, nil)
+}
+
+func (s *Server) getDevices(w http.ResponseWriter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	devices := append([]Device{}, s.devices...)
+	Success(w, devices)
+}
+
+type dateRange struct {
+	DateFrom string `json:"dateFrom"`
+	DateTo   string `json:"dateTo"`
+}
+
+func (d dateRange) contains(date string) bool {
+	return date >= d.DateFrom && date <= d.DateTo
+}
+
+func (s *Server) libraryMetaData(w http.ResponseWriter, body []byte) {
+	var dates dateRange
+	json.Unmarshal(body, &dates)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	type favorite struct {
+		NonFavorite int `json:"nonFavorite"`
+		Favorite    int `json:"Favorite"`
+	}
+
+	meta := make(map[string]map[string]*favorite)
+	for _, r := range s.recordings {
+		if !dates.contains(r.CreatedDate) {
+			continue
+		}
+		if meta[r.CreatedDate] == nil {
+			meta[r.CreatedDate] = make(map[string]*favorite)
+		}
+		if meta[r.CreatedDate][r.DeviceId] == nil {
+			meta[r.CreatedDate][r.DeviceId] = new(favorite)
+		}
+		meta[r.CreatedDate][r.DeviceId].NonFavorite++
+	}
+
+	Success(w, map[string]interface{}{"dateFrom": dates.DateFrom, "dateTo": dates.DateTo, "meta": meta})
+}
+
+func (s *Server) library(w http.ResponseWriter, body []byte) {
+	var dates dateRange
+	json.Unmarshal(body, &dates)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	library := []Recording{}
+	for _, r := range s.recordings {
+		if dates.contains(r.CreatedDate) {
+			library = append(library, r)
+		}
+	}
+
+	Success(w, library)
+}
+
+func (s *Server) recycle(w http.ResponseWriter, body []byte) {
+	var request struct {
+		Data []Recording `json:"data"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		Error(w, http.StatusBadRequest, "400", err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Recordings are identified by the device and when they were created, like the real api does.
+	recordings := s.recordings[:0]
+	for _, r := range s.recordings {
+		deleted := false
+		for _, d := range request.Data {
+			if r.DeviceId == d.DeviceId && r.CreatedDate == d.CreatedDate && r.UtcCreatedDate == d.UtcCreatedDate {
+				deleted = true
+				break
+			}
+		}
+		if !deleted {
+			recordings = append(recordings, r)
+		}
+	}
+	s.recordings = recordings
+
+	Success(w, nil)
+}
+
+func (s *Server) notify(w http.ResponseWriter, deviceId string, body []byte) {
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		Error(w, http.StatusBadRequest, "400", err.Error())
+		return
+	}
+
+	s.mutex.Lock()
+	s.notified = append(s.notified, msg)
+	responder := s.responder
+	if responder == nil {
+		responder = s.DefaultResponse
+	}
+	s.mutex.Unlock()
+
+	Success(w, nil)
+
+	if response, ok := responder(deviceId, msg); ok {
+		s.Publish(response)
+	}
+}
+
+// DefaultResponse is how notify requests are answered by default. The response has the same transId, resource and
+// properties as the request, and comes from the device the request was sent to:
+//
+// A "get" is answered with the properties set by SetState.
+// A "set" updates the properties of the resource with the ones in the request, and is answered with the result.
+func (s *Server) DefaultResponse(deviceId string, msg Message) (Message, bool) {
+	response := Message{
+		Action:     "is",
+		Resource:   msg.Resource,
+		Properties: msg.Properties,
+		TransId:    msg.TransId,
+		From:       msg.To,
+		To:         msg.From,
+	}
+
+	if strings.HasPrefix(msg.Resource, "subscriptions/") {
+		return response, true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.state[msg.To] == nil {
+		s.state[msg.To] = make(map[string]interface{})
+	}
+
+	switch msg.Action {
+	case "get":
+		response.Properties = s.state[msg.To][msg.Resource]
+	case "set":
+		current, _ := s.state[msg.To][msg.Resource].(map[string]interface{})
+		if properties, ok := msg.Properties.(map[string]interface{}); ok {
+			merged := make(map[string]interface{}, len(current)+len(properties))
+			for k, v := range current {
+				merged[k] = v
+			}
+			for k, v := range properties {
+				merged[k] = v
+			}
+			s.state[msg.To][msg.Resource] = merged
+			response.Properties = merged
+		} else if msg.Properties != nil {
+			s.state[msg.To][msg.Resource] = msg.Properties
+		}
+	}
+
+	return response, true
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// streamBuffer is how many messages can be waiting to be written to a stream before Publish blocks.
+const streamBuffer = 64
+
+// A stream is a client connected to the event stream.
+type stream struct {
+	messages chan []byte
+	dropped  chan struct{}
+}
+
+// subscribe serves the event stream (server-sent events) until the client goes away, or it's dropped.
+func (s *Server) subscribe(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		Error(w, http.StatusInternalServerError, "500", "streaming not supported")
+		return
+	}
+
+	st := &stream{messages: make(chan []byte, streamBuffer), dropped: make(chan struct{})}
+
+	s.mutex.Lock()
+	s.streams[st] = struct{}{}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.streams, st)
+		s.mutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	// Arlo confirms the connection before anything else is sent on it.
+	fmt.Fprint(w, "event: message\ndata: {\"status\":\"connected\"}\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case data := <-st.messages:
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-st.dropped:
+			return
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Publish sends msg to every client connected to the event stream. Use it to inject unsolicited events, like motion
+// detection or battery changes.
+func (s *Server) Publish(msg Message) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+
+	s.mutex.Lock()
+	streams := make([]*stream, 0, len(s.streams))
+	for st := range s.streams {
+		streams = append(streams, st)
+	}
+	s.mutex.Unlock()
+
+	for _, st := range streams {
+		select {
+		case st.messages <- data:
+		case <-st.dropped:
+		case <-s.done:
+		}
+	}
+}
+
+// Streams returns how many clients are connected to the event stream.
+func (s *Server) Streams() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.streams)
+}
+
+// DropStreams closes the connection of every client connected to the event stream, like a network failure would.
+func (s *Server) DropStreams() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for st := range s.streams {
+		close(st.dropped)
+		delete(s.streams, st)
+	}
+}
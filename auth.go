@@ -0,0 +1,383 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/jeffreydwalter/arlo-go/internal/request"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTwoFactorRequired is returned when a second factor is needed to finish logging in.
+var ErrTwoFactorRequired = errors.New("two-factor authentication required")
+
+// TwoFactorHandler supplies the second factor for LoginWithTwoFactor.
+type TwoFactorHandler interface {
+	// SelectFactor picks which of the account's factors the code should be sent to.
+	SelectFactor(factors []Factor) (Factor, error)
+	// Code returns the code that was sent to the factor, and whether this device should be trusted,
+	// which lets later logins from this client skip the second factor.
+	Code(factor Factor) (code string, trustDevice bool, err error)
+}
+
+// Auth is a login in progress. It lets you walk through two-factor authentication one step at a time:
+//
+//	auth, err := arlo.BeginLogin(user, pass)
+//	if auth.TwoFactorRequired() {
+//		factors, err := auth.Factors()
+//		err = auth.RequestCode(factors[0])
+//		a, err := auth.SubmitCode(code, true)
+//	} else {
+//		a, err := auth.Finish()
+//	}
+//
+// Use LoginWithTwoFactor if you'd rather supply the second factor with a callback.
+type Auth struct {
+	arlo           *Arlo
+	token          AuthToken
+	factorAuthCode string
+}
+
+// BeginLogin authenticates with the username and password, which is the first step of logging in.
+func BeginLogin(user string, pass string) (*Auth, error) {
+	return BeginLoginContext(context.Background(), user, pass)
+}
+
+// BeginLoginContext is like BeginLogin, but uses ctx for the request.
+func BeginLoginContext(ctx context.Context, user string, pass string) (*Auth, error) {
+	arlo, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+	return arlo.BeginLoginContext(ctx, user, pass)
+}
+
+// BeginLogin starts logging in an Arlo object made with NewClient, like the BeginLogin function does.
+func (a *Arlo) BeginLogin(user string, pass string) (*Auth, error) {
+	return a.BeginLoginContext(context.Background(), user, pass)
+}
+
+// BeginLoginContext is like BeginLogin, but uses ctx for the request.
+func (a *Arlo) BeginLoginContext(ctx context.Context, user string, pass string) (*Auth, error) {
+	a.setCredentials(user, pass)
+	return a.beginLogin(ctx)
+}
+
+func (a *Arlo) beginLogin(ctx context.Context) (*Auth, error) {
+	msg := "failed to login"
+
+	user, pass := a.userCredentials()
+	body := map[string]string{
+		"email":     user,
+		"password":  base64.StdEncoding.EncodeToString([]byte(pass)),
+		"language":  "en",
+		"EnvSource": "prod",
+	}
+	resp, err := a.authClient.PostContext(ctx, AuthUri, body, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+	defer resp.Body.Close()
+
+	var response AuthResponse
+	if err := resp.Decode(&response); err != nil {
+		return nil, err
+	}
+
+	if !response.Success() {
+		return nil, errors.WithMessage(metaError(resp, response.AuthMeta), msg)
+	}
+
+	auth := &Auth{arlo: a}
+	auth.setToken(response.Data)
+
+	return auth, nil
+}
+
+// setToken saves the token and uses it for the rest of the authentication api calls.
+func (auth *Auth) setToken(token AuthToken) {
+	auth.token = token
+	auth.arlo.authClient.AddHeader("Authorization", base64.StdEncoding.EncodeToString([]byte(token.Token)))
+}
+
+// TwoFactorRequired returns true if the account needs a second factor before the login can be finished.
+func (auth *Auth) TwoFactorRequired() bool {
+	return auth.token.MFA && !auth.token.AuthCompleted
+}
+
+// Factors returns the factors that a two-factor authentication code can be sent to.
+func (auth *Auth) Factors() ([]Factor, error) {
+	return auth.FactorsContext(context.Background())
+}
+
+// FactorsContext is like Factors, but uses ctx for the request.
+func (auth *Auth) FactorsContext(ctx context.Context) ([]Factor, error) {
+	msg := "failed to get two-factor authentication factors"
+
+	resp, err := auth.arlo.authClient.GetContext(ctx, fmt.Sprintf(GetFactorsUri, auth.token.Authenticated), nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+	defer resp.Body.Close()
+
+	var response FactorsResponse
+	if err := resp.Decode(&response); err != nil {
+		return nil, err
+	}
+
+	if !response.Success() {
+		return nil, errors.WithMessage(metaError(resp, response.AuthMeta), msg)
+	}
+
+	return response.Data.Items, nil
+}
+
+// RequestCode asks Arlo to send a two-factor authentication code to the factor.
+// For a factor of type FactorTypePush, the login is approved in the Arlo app, and SubmitCode should be called with an empty code.
+func (auth *Auth) RequestCode(factor Factor) error {
+	return auth.RequestCodeContext(context.Background(), factor)
+}
+
+// RequestCodeContext is like RequestCode, but uses ctx for the request.
+func (auth *Auth) RequestCodeContext(ctx context.Context, factor Factor) error {
+	msg := "failed to request two-factor authentication code"
+
+	body := map[string]string{
+		"factorId":   factor.FactorId,
+		"factorType": factor.FactorType,
+		"userId":     auth.token.UserId,
+	}
+	resp, err := auth.arlo.authClient.PostContext(ctx, StartAuthUri, body, nil)
+	if err != nil {
+		return errors.WithMessage(err, msg)
+	}
+	defer resp.Body.Close()
+
+	var response StartAuthResponse
+	if err := resp.Decode(&response); err != nil {
+		return err
+	}
+
+	if !response.Success() {
+		return errors.WithMessage(metaError(resp, response.AuthMeta), msg)
+	}
+
+	auth.factorAuthCode = response.Data.FactorAuthCode
+
+	return nil
+}
+
+// SubmitCode finishes two-factor authentication with the code that was sent by RequestCode, and then finishes the login.
+// If trustDevice is true, Arlo remembers this client and later logins can skip the second factor.
+func (auth *Auth) SubmitCode(code string, trustDevice bool) (*Arlo, error) {
+	return auth.SubmitCodeContext(context.Background(), code, trustDevice)
+}
+
+// SubmitCodeContext is like SubmitCode, but uses ctx for the requests.
+func (auth *Auth) SubmitCodeContext(ctx context.Context, code string, trustDevice bool) (*Arlo, error) {
+	if err := auth.submitCode(ctx, code, trustDevice); err != nil {
+		return nil, err
+	}
+	return auth.FinishContext(ctx)
+}
+
+func (auth *Auth) submitCode(ctx context.Context, code string, trustDevice bool) error {
+	msg := "failed to submit two-factor authentication code"
+
+	if auth.factorAuthCode == "" {
+		return errors.WithMessage(errors.New("no code was requested, call RequestCode() first"), msg)
+	}
+
+	body := map[string]interface{}{
+		"factorAuthCode":   auth.factorAuthCode,
+		"otp":              code,
+		"isBrowserTrusted": trustDevice,
+	}
+	resp, err := auth.arlo.authClient.PostContext(ctx, FinishAuthUri, body, nil)
+	if err != nil {
+		return errors.WithMessage(err, msg)
+	}
+	defer resp.Body.Close()
+
+	var response AuthResponse
+	if err := resp.Decode(&response); err != nil {
+		return err
+	}
+
+	if !response.Success() {
+		return errors.WithMessage(metaError(resp, response.AuthMeta), msg)
+	}
+
+	token := response.Data
+	if token.Authenticated == 0 {
+		token.Authenticated = auth.token.Authenticated
+	}
+	token.AuthCompleted = true
+	auth.setToken(token)
+
+	return nil
+}
+
+// Finish finishes the login once the account no longer needs a second factor, and returns the logged in Arlo object.
+// It returns ErrTwoFactorRequired if a second factor is still needed.
+func (auth *Auth) Finish() (*Arlo, error) {
+	return auth.FinishContext(context.Background())
+}
+
+// FinishContext is like Finish, but uses ctx for the requests.
+func (auth *Auth) FinishContext(ctx context.Context) (*Arlo, error) {
+	account, err := auth.finish(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := auth.arlo.loggedIn(ctx, account); err != nil {
+		return nil, errors.WithMessage(err, "failed to login")
+	}
+
+	return auth.arlo, nil
+}
+
+// finish validates the token and returns the account it belongs to.
+func (auth *Auth) finish(ctx context.Context) (Account, error) {
+	msg := "failed to login"
+
+	if auth.TwoFactorRequired() {
+		return Account{}, ErrTwoFactorRequired
+	}
+
+	resp, err := auth.arlo.authClient.GetContext(ctx, fmt.Sprintf(ValidateAccessTokenUri, auth.token.Authenticated), nil)
+	if err != nil {
+		return Account{}, errors.WithMessage(err, msg)
+	}
+	defer resp.Body.Close()
+
+	var response AuthMeta
+	if err := resp.Decode(&response); err != nil {
+		return Account{}, err
+	}
+
+	if !response.Success() {
+		return Account{}, errors.WithMessage(metaError(resp, response), msg)
+	}
+
+	// The token from the authentication api is also the token for the rest of the api.
+	auth.arlo.client.AddHeader("Authorization", auth.token.Token)
+
+	session, err := auth.arlo.CheckSessionContext(request.WithoutReauthentication(ctx))
+	if err != nil {
+		return Account{}, errors.WithMessage(err, msg)
+	}
+
+	account := session.Account
+	if account.Token == "" {
+		account.Token = auth.token.Token
+	}
+
+	// The authentication api reports when the token expires as a unix time.
+	auth.arlo.rwmutex.Lock()
+	if auth.token.ExpiresIn > 0 {
+		auth.arlo.expires = time.Unix(auth.token.ExpiresIn, 0)
+	}
+	auth.arlo.authApi = true
+	auth.arlo.rwmutex.Unlock()
+
+	return account, nil
+}
+
+// LoginWithTwoFactor logs in to an account, using handler to supply the second factor if the account needs one.
+// The handler is kept, and used again if the Arlo object has to log in again after its token expires.
+func LoginWithTwoFactor(user string, pass string, handler TwoFactorHandler) (*Arlo, error) {
+	return LoginWithTwoFactorContext(context.Background(), user, pass, handler)
+}
+
+// LoginWithTwoFactorContext is like LoginWithTwoFactor, but uses ctx for the requests.
+func LoginWithTwoFactorContext(ctx context.Context, user string, pass string, handler TwoFactorHandler) (*Arlo, error) {
+	arlo, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := arlo.LoginWithTwoFactorContext(ctx, user, pass, handler); err != nil {
+		return nil, err
+	}
+
+	return arlo, nil
+}
+
+// LoginWithTwoFactor logs in an Arlo object made with NewClient, like the LoginWithTwoFactor function does.
+func (a *Arlo) LoginWithTwoFactor(user string, pass string, handler TwoFactorHandler) error {
+	return a.LoginWithTwoFactorContext(context.Background(), user, pass, handler)
+}
+
+// LoginWithTwoFactorContext is like LoginWithTwoFactor, but uses ctx for the requests.
+func (a *Arlo) LoginWithTwoFactorContext(ctx context.Context, user string, pass string, handler TwoFactorHandler) error {
+	auth, err := a.BeginLoginContext(ctx, user, pass)
+	if err != nil {
+		return err
+	}
+
+	a.SetTwoFactorHandler(handler)
+
+	if err := auth.secondFactor(ctx, handler); err != nil {
+		return err
+	}
+
+	_, err = auth.FinishContext(ctx)
+	return err
+}
+
+// secondFactor uses handler to supply the second factor, if one is needed.
+func (auth *Auth) secondFactor(ctx context.Context, handler TwoFactorHandler) error {
+	if !auth.TwoFactorRequired() {
+		return nil
+	}
+
+	if handler == nil {
+		return ErrTwoFactorRequired
+	}
+
+	factors, err := auth.FactorsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(factors) == 0 {
+		return errors.WithMessage(errors.New("no two-factor authentication factors found"), "failed to login")
+	}
+
+	factor, err := handler.SelectFactor(factors)
+	if err != nil {
+		return errors.WithMessage(err, "failed to login")
+	}
+
+	if err := auth.RequestCodeContext(ctx, factor); err != nil {
+		return err
+	}
+
+	code, trustDevice, err := handler.Code(factor)
+	if err != nil {
+		return errors.WithMessage(err, "failed to login")
+	}
+
+	return auth.submitCode(ctx, code, trustDevice)
+}
@@ -17,21 +17,17 @@
 package arlo
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"net/http"
-	"time"
 
 	"github.com/pkg/errors"
 )
 
-const eventStreamTimeout = 10 * time.Second
-const pingTime = 30 * time.Second
-
-// A Basestation is a Device that's not type "camera" (basestation, arloq, arloqs, etc.).
-// This type is here just for semantics. Some methods explicitly require a device of a certain type.
+// A Basestation is a Device of type "basestation", which connects the devices that are its children to the cloud.
+// ArloQ and Bridge embed it, since they connect to the event stream the same way.
 type Basestation struct {
 	Device
-	eventStream *eventStream
 }
 
 // Basestations is a slice of Basestation objects.
@@ -39,149 +35,213 @@ type Basestations []Basestation
 
 // Find returns a basestation with the device id passed in.
 func (bs *Basestations) Find(deviceId string) *Basestation {
-	for _, b := range *bs {
-		if b.DeviceId == deviceId {
-			return &b
+	for i := range *bs {
+		if (*bs)[i].DeviceId == deviceId {
+			return &(*bs)[i]
 		}
 	}
 
 	return nil
 }
 
-// makeEventStreamRequest is a helper function sets up a response channel, sends a message to the event stream, and blocks waiting for the response.
-func (b *Basestation) makeEventStreamRequest(payload EventStreamPayload, msg string) (response *EventStreamResponse, err error) {
-	transId := genTransId()
-	payload.TransId = transId
+// BasestationCollection is the basestations of the account. It's a view of the devices, like DeviceCollection.
+type BasestationCollection struct {
+	view
+}
 
-	if err := b.IsConnected(); err != nil {
-		//if err := b.Subscribe(); err != nil {
-		return nil, errors.WithMessage(errors.WithMessage(err, msg), "failed to reconnect to event stream")
-		//}
+var basestationKinds = []DeviceKind{KindBasestation}
+
+// All returns the basestations, in the order the Arlo API returned them.
+func (bs BasestationCollection) All() []*Basestation {
+	return basestations(bs.list(nil))
+}
+
+// Range calls fn for each basestation, in order, until it returns false. See DeviceCollection.Range.
+func (bs BasestationCollection) Range(fn func(b *Basestation) bool) {
+	for _, b := range bs.All() {
+		if !fn(b) {
+			return
+		}
 	}
+}
 
-	subscriber := make(subscriber)
+// Find returns the basestation with the device id, or nil if there isn't one.
+func (bs BasestationCollection) Find(deviceId string) *Basestation {
+	b, _ := bs.findId(deviceId).(*Basestation)
+	return b
+}
 
-	// Add the response channel to the event stream queue so the response can be written to it.
-	b.eventStream.subscribe(transId, subscriber)
-	// Make sure we close and remove the response channel before returning.
-	defer b.eventStream.unsubscribe(transId)
+// FindByName returns the first basestation with the name, or nil if there isn't one.
+func (bs BasestationCollection) FindByName(name string) *Basestation {
+	b, _ := bs.find(byName(name)).(*Basestation)
+	return b
+}
 
-	// Send the payload to the event stream.
-	if err := b.NotifyEventStream(payload, msg); err != nil {
-		return nil, err
+// FindByUniqueId returns the basestation with the unique id, or nil if there isn't one.
+func (bs BasestationCollection) FindByUniqueId(uniqueId string) *Basestation {
+	b, _ := bs.find(byUniqueId(uniqueId)).(*Basestation)
+	return b
+}
+
+// FindByModel returns the basestations of the model.
+func (bs BasestationCollection) FindByModel(modelId string) []*Basestation {
+	return basestations(bs.list(byModel(modelId)))
+}
+
+func basestations(vs []interface{}) []*Basestation {
+	var basestations []*Basestation
+	for _, b := range vs {
+		basestations = append(basestations, b.(*Basestation))
 	}
+	return basestations
+}
 
-	timer := time.NewTimer(eventStreamTimeout)
-	defer timer.Stop()
+// makeEventStreamRequest is a helper function sets up a response channel, sends a message to the event stream, and blocks waiting for the response.
+// If the event stream drops while waiting for the response, the message is sent again once it reconnects.
+// ctx bounds the whole request, including waiting for the response.
+func (b *Basestation) makeEventStreamRequest(ctx context.Context, payload EventStreamPayload, msg string) (response *EventStreamResponse, err error) {
+	payload.TransId = genTransId()
 
-	// Wait for the response to come back from the event stream on the response channel.
-	select {
-	// If we get a response, return it to the caller.
-	case response := <-subscriber:
-		return response, nil
-	case err = <-b.eventStream.Error:
-		return nil, errors.Wrap(err, msg)
-	// If the event stream is closed, return an error about it.
-	case <-b.eventStream.Disconnected:
-		err = errors.New("event stream was closed before response was read")
-		return nil, errors.WithMessage(err, msg)
-	// If we timeout, return an error about it.
-	case <-timer.C:
-		err = fmt.Errorf("event stream response timed out after %.0f second", eventStreamTimeout.Seconds())
+	if err := b.IsConnected(); err != nil {
 		return nil, errors.WithMessage(err, msg)
 	}
+
+	return b.arlo.stream().request(ctx, payload, b.notify(msg), nil, msg)
 }
 
+// IsConnected returns an error if the basestation isn't subscribed to the event stream.
+// A basestation whose event stream dropped and is reconnecting is still considered to be connected.
 func (b *Basestation) IsConnected() error {
+	if !b.arlo.isSubscribed(b.DeviceId) {
+		return errors.New("basestation not connected to event stream")
+	}
+
 	// If the event stream is closed, return an error about it.
 	select {
-	case <-b.eventStream.Disconnected:
+	case <-b.disconnected():
 		return errors.New("basestation not connected to event stream")
 	default:
 		return nil
 	}
 }
 
-func (b *Basestation) Subscribe() error {
-	b.eventStream = newEventStream(BaseUrl+fmt.Sprintf(NotifyResponsesPushServiceUri, b.arlo.Account.Token), &http.Client{Jar: b.arlo.client.HttpClient.Jar})
-
-forLoop:
-	for {
-		// We blocking here because we can't really do anything with the event stream until we're connected.
-		// Once we have confirmation that we're connected to the event stream, we will "subscribe" to events.
-		select {
-		case connected := <-b.eventStream.listen():
-			if connected {
-				break forLoop
-			} else {
-				return errors.New("failed to subscribe to the event stream")
-			}
-		case <-b.eventStream.Disconnected:
-			err := errors.New("event stream was closed")
-			return errors.WithMessage(err, "failed to subscribe to the event stream")
-		}
+// disconnected returns a channel that's closed when the event stream is closed.
+func (b *Basestation) disconnected() <-chan struct{} {
+	if es := b.arlo.stream(); es != nil {
+		return es.Disconnected
 	}
+	closed := make(chan struct{})
+	close(closed)
+	return closed
+}
+
+// Subscribe subscribes the basestation to the event stream, which is shared by all of the basestations of the account.
+// The event stream is connected by the first basestation to subscribe, and is then kept alive in the background:
+// if the connection drops, it reconnects with exponential backoff and subscribes all of the basestations again.
+func (b *Basestation) Subscribe() error {
+	return b.SubscribeContext(context.Background())
+}
 
-	if err := b.Ping(); err != nil {
+// SubscribeContext is like Subscribe, but uses ctx for connecting to the event stream and subscribing the basestation.
+// Canceling ctx afterwards doesn't close the event stream, use Disconnect for that.
+func (b *Basestation) SubscribeContext(ctx context.Context) error {
+	if err := b.arlo.subscribe(ctx, b); err != nil {
 		return errors.WithMessage(err, "failed to subscribe to the event stream")
 	}
+	return nil
+}
 
-	// The Arlo event stream requires a "ping" every 30s.
-	go func() {
-		for {
-			time.Sleep(pingTime)
-			if err := b.Ping(); err != nil {
-				b.Disconnect()
-				break
-			}
-		}
-	}()
+// Events returns a listener that receives every message from this basestation's event stream that matches the filter.
+// Call Unsubscribe() on the listener when you're done with it.
+func (b *Basestation) Events(filter EventFilter) *EventListener {
+	return b.arlo.listeners.add(filter, b.DeviceId)
+}
 
-	return nil
+// OnEvent calls handler for every message from this basestation's event stream that matches the filter.
+// The handler is called from its own goroutine, one event at a time. Call Unsubscribe() on the returned listener to stop it.
+func (b *Basestation) OnEvent(filter EventFilter, handler func(*EventStreamResponse)) *EventListener {
+	return b.arlo.listeners.addFunc(filter, b.DeviceId, handler)
 }
 
 func (b *Basestation) Unsubscribe() error {
-	resp, err := b.arlo.get(UnsubscribeUri, b.XCloudId, nil)
+	return b.UnsubscribeContext(context.Background())
+}
+
+// UnsubscribeContext is like Unsubscribe, but uses ctx for the request.
+func (b *Basestation) UnsubscribeContext(ctx context.Context) error {
+	resp, err := b.arlo.get(ctx, UnsubscribeUri, b.XCloudId, nil)
 	return checkRequest(resp, err, "failed to unsubscribe from event stream")
 }
 
+// Disconnect unsubscribes the basestation from the event stream. The event stream is closed when the last basestation disconnects.
 func (b *Basestation) Disconnect() error {
-	// disconnect channel to stop event stream.
-	if b.eventStream != nil {
-		b.eventStream.disconnect()
-	}
+	b.arlo.unsubscribe(b.DeviceId)
 	return nil
 }
 
 // Ping makes a call to the subscriptions endpoint. The Arlo event stream requires this message to be sent every 30s.
 func (b *Basestation) Ping() error {
-	payload := EventStreamPayload{
+	return b.PingContext(context.Background())
+}
+
+// PingContext is like Ping, but uses ctx for the request.
+func (b *Basestation) PingContext(ctx context.Context) error {
+	if _, err := b.makeEventStreamRequest(ctx, b.subscriptionPayload(), "failed to ping the event stream"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (b *Basestation) subscriptionPayload() EventStreamPayload {
+	return EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("subscriptions/%s_%s", b.UserId, TransIdPrefix),
 		PublishResponse: false,
 		Properties:      map[string][1]string{"devices": {b.DeviceId}},
+		TransId:         genTransId(),
 		From:            fmt.Sprintf("%s_%s", b.UserId, TransIdPrefix),
 		To:              b.DeviceId,
 	}
+}
 
-	if _, err := b.makeEventStreamRequest(payload, "failed to ping the event stream"); err != nil {
-		return err
+// notify returns a function that sends a payload to the basestation, for use with eventStream.request().
+func (b *Basestation) notify(msg string) func(context.Context, EventStreamPayload) error {
+	return func(ctx context.Context, payload EventStreamPayload) error {
+		return b.NotifyEventStreamContext(ctx, payload, msg)
 	}
-	return nil
 }
 
 func (b *Basestation) NotifyEventStream(payload EventStreamPayload, msg string) error {
-	resp, err := b.arlo.post(fmt.Sprintf(NotifyUri, b.DeviceId), b.XCloudId, payload, nil)
+	return b.NotifyEventStreamContext(context.Background(), payload, msg)
+}
+
+// NotifyEventStreamContext is like NotifyEventStream, but uses ctx for the request.
+func (b *Basestation) NotifyEventStreamContext(ctx context.Context, payload EventStreamPayload, msg string) error {
+	release, err := b.arlo.notifyLimiter(b.DeviceId).Acquire(ctx)
+	if err != nil {
+		return errors.WithMessage(err, msg)
+	}
+	defer release()
+
+	resp, err := b.arlo.post(ctx, fmt.Sprintf(NotifyUri, b.DeviceId), b.XCloudId, payload, nil)
 	if err := checkRequest(resp, err, msg); err != nil {
-		return errors.WithMessage(err, "failed to notify event stream")
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.TransId = payload.TransId
+		}
+		return err
 	}
-	defer resp.Body.Close()
 
 	return nil
 }
 
-func (b *Basestation) GetState() (response *EventStreamResponse, err error) {
+// GetState returns the state of the basestation: its versions, time zone, connectivity, etc.
+func (b *Basestation) GetState() (state *BasestationState, err error) {
+	return b.GetStateContext(context.Background())
+}
 
+// GetStateContext is like GetState, but uses ctx for the request.
+func (b *Basestation) GetStateContext(ctx context.Context) (state *BasestationState, err error) {
 	payload := EventStreamPayload{
 		Action:          "get",
 		Resource:        "basestation",
@@ -190,10 +250,27 @@ func (b *Basestation) GetState() (response *EventStreamResponse, err error) {
 		To:              b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to get basestation state")
+	msg := "failed to get basestation state"
+
+	response, err := b.makeEventStreamRequest(ctx, payload, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	state = &BasestationState{}
+	if err := decodeState(response, state, &state.Raw, msg); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// GetAssociatedCamerasState returns the state of each of the cameras connected to the basestation.
+func (b *Basestation) GetAssociatedCamerasState() (cameras []CameraState, err error) {
+	return b.GetAssociatedCamerasStateContext(context.Background())
 }
 
-func (b *Basestation) GetAssociatedCamerasState() (response *EventStreamResponse, err error) {
+// GetAssociatedCamerasStateContext is like GetAssociatedCamerasState, but uses ctx for the request.
+func (b *Basestation) GetAssociatedCamerasStateContext(ctx context.Context) (cameras []CameraState, err error) {
 	payload := EventStreamPayload{
 		Action:          "get",
 		Resource:        "cameras",
@@ -202,10 +279,35 @@ func (b *Basestation) GetAssociatedCamerasState() (response *EventStreamResponse
 		To:              b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to get associated cameras state")
+	msg := "failed to get associated cameras state"
+
+	response, err := b.makeEventStreamRequest(ctx, payload, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []json.RawMessage
+	if err := response.Decode(&raw); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	cameras = make([]CameraState, len(raw))
+	for i := range raw {
+		if err := json.Unmarshal(raw[i], &cameras[i]); err != nil {
+			return nil, errors.WithMessage(errors.Wrap(err, "failed to decode camera state"), msg)
+		}
+		cameras[i].Raw = raw[i]
+	}
+	return cameras, nil
+}
+
+// GetRules returns the rules of the basestation, which its modes are made of.
+func (b *Basestation) GetRules() (rules *RulesList, err error) {
+	return b.GetRulesContext(context.Background())
 }
 
-func (b *Basestation) GetRules() (response *EventStreamResponse, err error) {
+// GetRulesContext is like GetRules, but uses ctx for the request.
+func (b *Basestation) GetRulesContext(ctx context.Context) (rules *RulesList, err error) {
 	payload := EventStreamPayload{
 		Action:          "get",
 		Resource:        "rules",
@@ -214,10 +316,27 @@ func (b *Basestation) GetRules() (response *EventStreamResponse, err error) {
 		To:              b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to get rules")
+	msg := "failed to get rules"
+
+	response, err := b.makeEventStreamRequest(ctx, payload, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	rules = &RulesList{}
+	if err := decodeState(response, rules, &rules.Raw, msg); err != nil {
+		return nil, err
+	}
+	return rules, nil
 }
 
-func (b *Basestation) GetCalendarMode() (response *EventStreamResponse, err error) {
+// GetCalendarMode returns whether calendar mode is active, and the schedule it follows.
+func (b *Basestation) GetCalendarMode() (schedule *ScheduleState, err error) {
+	return b.GetCalendarModeContext(context.Background())
+}
+
+// GetCalendarModeContext is like GetCalendarMode, but uses ctx for the request.
+func (b *Basestation) GetCalendarModeContext(ctx context.Context) (schedule *ScheduleState, err error) {
 	payload := EventStreamPayload{
 		Action:          "get",
 		Resource:        "schedule",
@@ -226,13 +345,29 @@ func (b *Basestation) GetCalendarMode() (response *EventStreamResponse, err erro
 		To:              b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to get schedule")
+	msg := "failed to get schedule"
+
+	response, err := b.makeEventStreamRequest(ctx, payload, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule = &ScheduleState{}
+	if err := decodeState(response, schedule, &schedule.Raw, msg); err != nil {
+		return nil, err
+	}
+	return schedule, nil
 }
 
 // SetCalendarMode toggles calendar mode.
 // NOTE: The Arlo API seems to disable calendar mode when switching to other modes, if it's enabled.
 // You should probably do the same, although, the UI reflects the switch from calendar mode to say armed mode without explicitly setting calendar mode to inactive.
 func (b *Basestation) SetCalendarMode(active bool) (response *EventStreamResponse, err error) {
+	return b.SetCalendarModeContext(context.Background(), active)
+}
+
+// SetCalendarModeContext is like SetCalendarMode, but uses ctx for the request.
+func (b *Basestation) SetCalendarModeContext(ctx context.Context, active bool) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        "schedule",
@@ -244,10 +379,16 @@ func (b *Basestation) SetCalendarMode(active bool) (response *EventStreamRespons
 		To:   b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to set schedule")
+	return b.makeEventStreamRequest(ctx, payload, "failed to set schedule")
+}
+
+// GetModes returns the modes of the basestation, and which one is active.
+func (b *Basestation) GetModes() (modes *ModesList, err error) {
+	return b.GetModesContext(context.Background())
 }
 
-func (b *Basestation) GetModes() (response *EventStreamResponse, err error) {
+// GetModesContext is like GetModes, but uses ctx for the request.
+func (b *Basestation) GetModesContext(ctx context.Context) (modes *ModesList, err error) {
 	payload := EventStreamPayload{
 		Action:          "get",
 		Resource:        "modes",
@@ -256,10 +397,26 @@ func (b *Basestation) GetModes() (response *EventStreamResponse, err error) {
 		To:              b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to get modes")
+	msg := "failed to get modes"
+
+	response, err := b.makeEventStreamRequest(ctx, payload, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	modes = &ModesList{}
+	if err := decodeState(response, modes, &modes.Raw, msg); err != nil {
+		return nil, err
+	}
+	return modes, nil
 }
 
 func (b *Basestation) SetCustomMode(mode string) (response *EventStreamResponse, err error) {
+	return b.SetCustomModeContext(context.Background(), mode)
+}
+
+// SetCustomModeContext is like SetCustomMode, but uses ctx for the request.
+func (b *Basestation) SetCustomModeContext(ctx context.Context, mode string) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        "modes",
@@ -271,10 +428,15 @@ func (b *Basestation) SetCustomMode(mode string) (response *EventStreamResponse,
 		To:   b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to set mode")
+	return b.makeEventStreamRequest(ctx, payload, "failed to set mode")
 }
 
 func (b *Basestation) DeleteMode(mode string) (response *EventStreamResponse, err error) {
+	return b.DeleteModeContext(context.Background(), mode)
+}
+
+// DeleteModeContext is like DeleteMode, but uses ctx for the request.
+func (b *Basestation) DeleteModeContext(ctx context.Context, mode string) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "delete",
 		Resource:        fmt.Sprintf("modes/%s", mode),
@@ -283,49 +445,43 @@ func (b *Basestation) DeleteMode(mode string) (response *EventStreamResponse, er
 		To:              b.DeviceId,
 	}
 
-	return b.makeEventStreamRequest(payload, "failed to set mode")
+	return b.makeEventStreamRequest(ctx, payload, "failed to set mode")
 }
 
 func (b *Basestation) Arm() (response *EventStreamResponse, err error) {
-	return b.SetCustomMode("mode1")
+	return b.ArmContext(context.Background())
+}
+
+// ArmContext is like Arm, but uses ctx for the request.
+func (b *Basestation) ArmContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return b.SetCustomModeContext(ctx, "mode1")
 }
 
 func (b *Basestation) Disarm() (response *EventStreamResponse, err error) {
-	return b.SetCustomMode("mode0")
+	return b.DisarmContext(context.Background())
+}
+
+// DisarmContext is like Disarm, but uses ctx for the request.
+func (b *Basestation) DisarmContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return b.SetCustomModeContext(ctx, "mode0")
 }
 
+// SirenOn sounds the built-in siren of the basestation with DefaultSirenOptions. Use Siren() for other options.
 func (b *Basestation) SirenOn() (response *EventStreamResponse, err error) {
-	payload := EventStreamPayload{
-		Action:          "set",
-		Resource:        "siren",
-		PublishResponse: true,
-		Properties: SirenProperties{
-			SirenState: "on",
-			Duration:   300,
-			Volume:     8,
-			Pattern:    "alarm",
-		},
-		From: fmt.Sprintf("%s_%s", b.UserId, TransIdPrefix),
-		To:   b.DeviceId,
-	}
+	return b.SirenOnContext(context.Background())
+}
 
-	return b.makeEventStreamRequest(payload, "failed to get modes")
+// SirenOnContext is like SirenOn, but uses ctx for the request.
+func (b *Basestation) SirenOnContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return b.Siren().OnContext(ctx, DefaultSirenOptions)
 }
 
+// SirenOff silences the built-in siren of the basestation.
 func (b *Basestation) SirenOff() (response *EventStreamResponse, err error) {
-	payload := EventStreamPayload{
-		Action:          "set",
-		Resource:        "siren",
-		PublishResponse: true,
-		Properties: SirenProperties{
-			SirenState: "off",
-			Duration:   300,
-			Volume:     8,
-			Pattern:    "alarm",
-		},
-		From: fmt.Sprintf("%s_%s", b.UserId, TransIdPrefix),
-		To:   b.DeviceId,
-	}
+	return b.SirenOffContext(context.Background())
+}
 
-	return b.makeEventStreamRequest(payload, "failed to get modes")
+// SirenOffContext is like SirenOff, but uses ctx for the request.
+func (b *Basestation) SirenOffContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return b.Siren().OffContext(ctx)
 }
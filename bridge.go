@@ -0,0 +1,89 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+// A Bridge is an Arlo Bridge, which connects lights, and some cameras, to the cloud. It connects to the event stream
+// like a basestation does, and the devices connected to it are controlled through it.
+type Bridge struct {
+	Basestation
+}
+
+// Bridges is a slice of Bridge objects.
+type Bridges []Bridge
+
+// Find returns a bridge with the device id passed in.
+func (bs *Bridges) Find(deviceId string) *Bridge {
+	for i := range *bs {
+		if (*bs)[i].DeviceId == deviceId {
+			return &(*bs)[i]
+		}
+	}
+
+	return nil
+}
+
+// BridgeCollection is the bridges of the account. It's a view of the devices, like DeviceCollection.
+type BridgeCollection struct {
+	view
+}
+
+var bridgeKinds = []DeviceKind{KindBridge}
+
+// All returns the bridges, in the order the Arlo API returned them.
+func (bs BridgeCollection) All() []*Bridge {
+	return bridges(bs.list(nil))
+}
+
+// Range calls fn for each bridge, in order, until it returns false. See DeviceCollection.Range.
+func (bs BridgeCollection) Range(fn func(b *Bridge) bool) {
+	for _, b := range bs.All() {
+		if !fn(b) {
+			return
+		}
+	}
+}
+
+// Find returns the bridge with the device id, or nil if there isn't one.
+func (bs BridgeCollection) Find(deviceId string) *Bridge {
+	b, _ := bs.findId(deviceId).(*Bridge)
+	return b
+}
+
+// FindByName returns the first bridge with the name, or nil if there isn't one.
+func (bs BridgeCollection) FindByName(name string) *Bridge {
+	b, _ := bs.find(byName(name)).(*Bridge)
+	return b
+}
+
+// FindByUniqueId returns the bridge with the unique id, or nil if there isn't one.
+func (bs BridgeCollection) FindByUniqueId(uniqueId string) *Bridge {
+	b, _ := bs.find(byUniqueId(uniqueId)).(*Bridge)
+	return b
+}
+
+// FindByModel returns the bridges of the model.
+func (bs BridgeCollection) FindByModel(modelId string) []*Bridge {
+	return bridges(bs.list(byModel(modelId)))
+}
+
+func bridges(vs []interface{}) []*Bridge {
+	var bridges []*Bridge
+	for _, b := range vs {
+		bridges = append(bridges, b.(*Bridge))
+	}
+	return bridges
+}
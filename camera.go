@@ -17,13 +17,18 @@
 package arlo
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
+// snapshotTimeout is how long to wait for a snapshot, when the caller doesn't set a deadline.
+const snapshotTimeout = 30 * time.Second
+
 // A Camera is a Device of type "camera".
 // This type is here just for semantics. Some methods explicitly require a device of a certain type.
 type Camera Device
@@ -33,17 +38,74 @@ type Cameras []Camera
 
 // Find returns a camera with the device id passed in.
 func (cs *Cameras) Find(deviceId string) *Camera {
-	for _, c := range *cs {
-		if c.DeviceId == deviceId {
-			return &c
+	for i := range *cs {
+		if (*cs)[i].DeviceId == deviceId {
+			return &(*cs)[i]
 		}
 	}
 
 	return nil
 }
 
+// CameraCollection is the cameras of the account. It's a view of the devices, like DeviceCollection.
+type CameraCollection struct {
+	view
+}
+
+var cameraKinds = []DeviceKind{KindCamera}
+
+// All returns the cameras, in the order the Arlo API returned them.
+func (cs CameraCollection) All() []*Camera {
+	return cameras(cs.list(nil))
+}
+
+// Range calls fn for each camera, in order, until it returns false. See DeviceCollection.Range.
+func (cs CameraCollection) Range(fn func(c *Camera) bool) {
+	for _, c := range cs.All() {
+		if !fn(c) {
+			return
+		}
+	}
+}
+
+// Find returns the camera with the device id, or nil if there isn't one.
+func (cs CameraCollection) Find(deviceId string) *Camera {
+	c, _ := cs.findId(deviceId).(*Camera)
+	return c
+}
+
+// FindByName returns the first camera with the name, or nil if there isn't one.
+func (cs CameraCollection) FindByName(name string) *Camera {
+	c, _ := cs.find(byName(name)).(*Camera)
+	return c
+}
+
+// FindByUniqueId returns the camera with the unique id, or nil if there isn't one.
+func (cs CameraCollection) FindByUniqueId(uniqueId string) *Camera {
+	c, _ := cs.find(byUniqueId(uniqueId)).(*Camera)
+	return c
+}
+
+// FindByModel returns the cameras of the model.
+func (cs CameraCollection) FindByModel(modelId string) []*Camera {
+	return cameras(cs.list(byModel(modelId)))
+}
+
+func cameras(vs []interface{}) []*Camera {
+	var cameras []*Camera
+	for _, c := range vs {
+		cameras = append(cameras, c.(*Camera))
+	}
+	return cameras
+}
+
 // On turns a camera on; meaning it will detect and record events.
 func (c *Camera) On() (response *EventStreamResponse, err error) {
+	return c.OnContext(context.Background())
+}
+
+// OnContext is like On, but uses ctx for the request.
+func (c *Camera) OnContext(ctx context.Context) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -57,16 +119,21 @@ func (c *Camera) On() (response *EventStreamResponse, err error) {
 
 	msg := "failed to turn camera on"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // On turns a camera off; meaning it won't detect and record events.
 func (c *Camera) Off() (response *EventStreamResponse, err error) {
+	return c.OffContext(context.Background())
+}
+
+// OffContext is like Off, but uses ctx for the request.
+func (c *Camera) OffContext(ctx context.Context) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -80,18 +147,23 @@ func (c *Camera) Off() (response *EventStreamResponse, err error) {
 
 	msg := "failed to turn camera off"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // SetBrightness sets the camera brightness.
 // NOTE: Brightness is between -2 and 2 in increments of 1 (-2, -1, 0, 1, 2).
 // Setting it to an invalid value has no effect.
 func (c *Camera) SetBrightness(brightness int) (response *EventStreamResponse, err error) {
+	return c.SetBrightnessContext(context.Background(), brightness)
+}
+
+// SetBrightnessContext is like SetBrightness, but uses ctx for the request.
+func (c *Camera) SetBrightnessContext(ctx context.Context, brightness int) (response *EventStreamResponse, err error) {
 	// Sanity check; if the values are above or below the allowed limits, set them to their limit.
 	if brightness < -2 {
 		brightness = -2
@@ -112,15 +184,20 @@ func (c *Camera) SetBrightness(brightness int) (response *EventStreamResponse, e
 
 	msg := "failed to set camera brightness"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) EnableMotionAlerts(sensitivity int, zones []string) (response *EventStreamResponse, err error) {
+	return c.EnableMotionAlertsContext(context.Background(), sensitivity, zones)
+}
+
+// EnableMotionAlertsContext is like EnableMotionAlerts, but uses ctx for the request.
+func (c *Camera) EnableMotionAlertsContext(ctx context.Context, sensitivity int, zones []string) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -138,15 +215,20 @@ func (c *Camera) EnableMotionAlerts(sensitivity int, zones []string) (response *
 
 	msg := "failed to enable motion alerts"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) DisableMotionAlerts(sensitivity int, zones []string) (response *EventStreamResponse, err error) {
+	return c.DisableMotionAlertsContext(context.Background(), sensitivity, zones)
+}
+
+// DisableMotionAlertsContext is like DisableMotionAlerts, but uses ctx for the request.
+func (c *Camera) DisableMotionAlertsContext(ctx context.Context, sensitivity int, zones []string) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -164,15 +246,20 @@ func (c *Camera) DisableMotionAlerts(sensitivity int, zones []string) (response
 
 	msg := "failed to enable motion alerts"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) EnableAudioAlerts(sensitivity int) (response *EventStreamResponse, err error) {
+	return c.EnableAudioAlertsContext(context.Background(), sensitivity)
+}
+
+// EnableAudioAlertsContext is like EnableAudioAlerts, but uses ctx for the request.
+func (c *Camera) EnableAudioAlertsContext(ctx context.Context, sensitivity int) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -189,15 +276,20 @@ func (c *Camera) EnableAudioAlerts(sensitivity int) (response *EventStreamRespon
 
 	msg := "failed to enable audio alerts"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 func (c *Camera) DisableAudioAlerts(sensitivity int) (response *EventStreamResponse, err error) {
+	return c.DisableAudioAlertsContext(context.Background(), sensitivity)
+}
+
+// DisableAudioAlertsContext is like DisableAudioAlerts, but uses ctx for the request.
+func (c *Camera) DisableAudioAlertsContext(ctx context.Context, sensitivity int) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -214,18 +306,23 @@ func (c *Camera) DisableAudioAlerts(sensitivity int) (response *EventStreamRespo
 
 	msg := "failed to disable audio alerts"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // PushToTalk starts a push-to-talk session.
 // FIXME: This feature requires more API calls to make it actually work, and I haven't figure out how to fully implement it.
 // It appears that the audio stream is Real-Time Transport Protocol (RTP), which requires a player (ffmpeg?) to consume the stream.
 func (c *Camera) PushToTalk() error {
+	return c.PushToTalkContext(context.Background())
+}
+
+// PushToTalkContext is like PushToTalk, but uses ctx for the request.
+func (c *Camera) PushToTalkContext(ctx context.Context) error {
 	/*
 		processResponse: function(e) {
 		            if (g.pc)
@@ -396,12 +493,23 @@ func (c *Camera) PushToTalk() error {
 		        }
 		    };
 	*/
-	resp, err := c.arlo.get(fmt.Sprintf(PttUri, c.UniqueId), c.XCloudId, nil)
-	return checkRequest(resp, err, "failed to enable push to talk")
+	msg := "failed to enable push to talk"
+
+	if err := (*Device)(c).require(ctx, FeaturePushToTalk); err != nil {
+		return errors.WithMessage(err, msg)
+	}
+
+	resp, err := c.arlo.get(ctx, fmt.Sprintf(PttUri, c.UniqueId), c.XCloudId, nil)
+	return checkRequest(resp, err, msg)
 }
 
 // action: disabled OR recordSnapshot OR recordVideo
 func (c *Camera) SetAlertNotificationMethods(action string, email, push bool) (response *EventStreamResponse, err error) {
+	return c.SetAlertNotificationMethodsContext(context.Background(), action, email, push)
+}
+
+// SetAlertNotificationMethodsContext is like SetAlertNotificationMethods, but uses ctx for the request.
+func (c *Camera) SetAlertNotificationMethodsContext(ctx context.Context, action string, email, push bool) (response *EventStreamResponse, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -424,12 +532,12 @@ func (c *Camera) SetAlertNotificationMethods(action string, email, push bool) (r
 
 	msg := "failed to set alert notification methods"
 
-	b := c.arlo.Basestations.Find(c.ParentId)
+	b := c.arlo.basestation(c.ParentId)
 	if b == nil {
 		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
 		return nil, errors.WithMessage(err, msg)
 	}
-	return b.makeEventStreamRequest(payload, msg)
+	return b.makeEventStreamRequest(ctx, payload, msg)
 }
 
 // StartStream returns a json object containing the rtmps url to the requested video stream.
@@ -438,6 +546,11 @@ func (c *Camera) SetAlertNotificationMethods(action string, email, push bool) (r
 // If you call StartStream(), you have to start reading data from the stream, or streaming will be cancelled
 // and taking a snapshot may fail (since it requires the stream to be active).
 func (c *Camera) StartStream() (url string, err error) {
+	return c.StartStreamContext(context.Background())
+}
+
+// StartStreamContext is like StartStream, but uses ctx for the request.
+func (c *Camera) StartStreamContext(ctx context.Context) (url string, err error) {
 	payload := EventStreamPayload{
 		Action:          "set",
 		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
@@ -453,7 +566,7 @@ func (c *Camera) StartStream() (url string, err error) {
 
 	msg := "failed to start stream"
 
-	resp, err := c.arlo.post(StartStreamUri, c.XCloudId, payload, nil)
+	resp, err := c.arlo.post(ctx, StartStreamUri, c.XCloudId, payload, nil)
 	if err != nil {
 		return "", errors.WithMessage(err, msg)
 	}
@@ -465,14 +578,46 @@ func (c *Camera) StartStream() (url string, err error) {
 	}
 
 	if !response.Success {
-		return "", errors.WithMessage(errors.New("status was false"), msg)
+		return "", errors.WithMessage(statusError(resp, response.Status), msg)
 	}
 
 	response.Data.URL = strings.Replace(response.Data.URL, "rtsp://", "rtsps://", 1)
 
+	c.arlo.setStreaming(c.DeviceId, true)
+
 	return response.Data.URL, nil
 }
 
+// StopStream stops the video stream started by StartStream().
+func (c *Camera) StopStream() error {
+	return c.StopStreamContext(context.Background())
+}
+
+// StopStreamContext is like StopStream, but uses ctx for the request.
+func (c *Camera) StopStreamContext(ctx context.Context) error {
+	payload := EventStreamPayload{
+		Action:          "set",
+		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
+		PublishResponse: true,
+		Properties: map[string]string{
+			"activityState": "stopUserStream",
+			"cameraId":      c.DeviceId,
+		},
+		TransId: genTransId(),
+		From:    fmt.Sprintf("%s_%s", c.UserId, TransIdPrefix),
+		To:      c.ParentId,
+	}
+
+	resp, err := c.arlo.post(ctx, StopStreamUri, c.XCloudId, payload, nil)
+	if err := checkRequest(resp, err, "failed to stop stream"); err != nil {
+		return err
+	}
+
+	c.arlo.setStreaming(c.DeviceId, false)
+
+	return nil
+}
+
 // TakeSnapshot causes the camera to snapshot while recording.
 // NOTE: You MUST call StartStream() before calling this function.
 // If you call StartStream(), you have to start reading data from the stream, or streaming will be cancelled
@@ -481,80 +626,175 @@ func (c *Camera) StartStream() (url string, err error) {
 // NOTE: You should not use this function is you just want a snapshot and aren't intending to stream.
 // Use TriggerFullFrameSnapshot() instead.
 //
-// NOTE: Use DownloadSnapshot() to download the actual image file.
-// TODO: Need to refactor the even stream code to allow handling of events whose transIds don't correlate. :/
-func (c *Camera) TakeSnapshot() (response *EventStreamResponse, err error) {
+// The presignedContentUrl url of the snapshot is returned. Use DownloadFile() to download the actual image file.
+func (c *Camera) TakeSnapshot() (url string, err error) {
+	return c.TakeSnapshotContext(context.Background())
+}
 
-	return nil, errors.New("TakeSnapshot not implemented")
-	/*
-		msg := "failed to take snapshot"
+// TakeSnapshotContext is like TakeSnapshot, but gives up when ctx is done.
+// If ctx doesn't have a deadline, it gives up after waiting 30 seconds for the snapshot.
+func (c *Camera) TakeSnapshotContext(ctx context.Context) (url string, err error) {
+	msg := "failed to take snapshot"
 
-		body := map[string]string{"deviceId": c.DeviceId, "parentId": c.ParentId, "xcloudId": c.XCloudId, "olsonTimeZone": c.Properties.OlsonTimeZone}
-		resp, err := c.arlo.post(TakeSnapshotUri, c.XCloudId, body, nil)
-		if err := checkRequest(resp, err, msg); err != nil {
-			return nil, errors.WithMessage(err, msg)
-		}
-	*/
+	if !c.arlo.isStreaming(c.DeviceId) {
+		err := fmt.Errorf("camera (%s) is not streaming, call StartStream() first", c.DeviceId)
+		return "", errors.WithMessage(err, msg)
+	}
 
-	// TODO: Need to write the code to handle the event stream message.
-	/*
-			def callback(self, event):
-				if event.get("deviceId") == camera.get("deviceId") and event.get("resource") == "mediaUploadNotification":
-					presigned_content_url = event.get("presignedContentUrl")
-					if presigned_content_url is not None:
-		r				return presigned_content_url
-	*/
+	b := c.arlo.basestation(c.ParentId)
+	if b == nil {
+		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
+		return "", errors.WithMessage(err, msg)
+	}
+
+	if err := b.IsConnected(); err != nil {
+		return "", errors.WithMessage(err, msg)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, snapshotTimeout)
+		defer cancel()
+	}
+
+	// Start listening before taking the snapshot, so the event can't be missed.
+	listener := b.Events(EventFilter{
+		Resource: "mediaUploadNotification",
+		DeviceId: c.DeviceId,
+	})
+	defer listener.Unsubscribe()
+
+	body := map[string]string{"deviceId": c.DeviceId, "parentId": c.ParentId, "xcloudId": c.XCloudId, "olsonTimeZone": (*Device)(c).Snapshot().Properties.OlsonTimeZone}
+	resp, err := c.arlo.post(ctx, TakeSnapshotUri, c.XCloudId, body, nil)
+	if err := checkRequest(resp, err, msg); err != nil {
+		return "", err
+	}
+
+	for {
+		select {
+		case event, ok := <-listener.Events:
+			if !ok {
+				return "", errors.WithMessage(errors.New("event listener was closed"), msg)
+			}
+			if event.PresignedContentUrl != "" {
+				return event.PresignedContentUrl, nil
+			}
+		case <-b.disconnected():
+			err := errors.New("event stream was closed before the snapshot was available")
+			return "", errors.WithMessage(err, msg)
+		case <-ctx.Done():
+			return "", errors.WithMessage(ctx.Err(), msg)
+		}
+	}
 }
 
 // TriggerFullFrameSnapshot causes the camera to record a full-frame snapshot.
 // The presignedFullFrameSnapshotUrl url is returned.
-// Use DownloadSnapshot() to download the actual image file.
-// TODO: Need to refactor the even stream code to allow handling of events whose transIds don't correlate. :/
-func (c *Camera) TriggerFullFrameSnapshot() (response *EventStreamResponse, err error) {
+// Use DownloadFile() to download the actual image file, or DownloadFullFrameSnapshot() to do both at once.
+func (c *Camera) TriggerFullFrameSnapshot() (url string, err error) {
+	return c.TriggerFullFrameSnapshotContext(context.Background())
+}
 
-	return nil, errors.New("TriggerFullFrameSnapshot not implemented")
-	/*
-		payload := EventStreamPayload{
-			Action:          "set",
-			Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
-			PublishResponse: true,
-			Properties: map[string]string{
-				"activityState": "fullFrameSnapshot",
-			},
-			TransId: genTransId(),
-			From:    fmt.Sprintf("%s_%s", c.UserId, TransIdPrefix),
-			To:      c.ParentId,
-		}
+// TriggerFullFrameSnapshotContext is like TriggerFullFrameSnapshot, but gives up when ctx is done.
+// If ctx doesn't have a deadline, it gives up after waiting 30 seconds for the snapshot.
+func (c *Camera) TriggerFullFrameSnapshotContext(ctx context.Context) (url string, err error) {
+	msg := "failed to trigger full-frame snapshot"
+
+	b := c.arlo.basestation(c.ParentId)
+	if b == nil {
+		err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
+		return "", errors.WithMessage(err, msg)
+	}
+
+	if err := b.IsConnected(); err != nil {
+		return "", errors.WithMessage(err, msg)
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, snapshotTimeout)
+		defer cancel()
+	}
 
-		msg := "failed to trigger full-frame snapshot"
+	// Start listening before triggering the snapshot, so the event can't be missed.
+	listener := b.Events(EventFilter{
+		Resource: fmt.Sprintf("cameras/%s", c.DeviceId),
+		Action:   "fullFrameSnapshotAvailable",
+		From:     b.DeviceId,
+	})
+	defer listener.Unsubscribe()
 
-		b := c.arlo.Basestations.Find(c.ParentId)
-		if b == nil {
-			err := fmt.Errorf("basestation (%s) not found for camera (%s)", c.ParentId, c.DeviceId)
-			return nil, errors.WithMessage(err, msg)
+	payload := EventStreamPayload{
+		Action:          "set",
+		Resource:        fmt.Sprintf("cameras/%s", c.DeviceId),
+		PublishResponse: true,
+		Properties: map[string]string{
+			"activityState": "fullFrameSnapshot",
+		},
+		TransId: genTransId(),
+		From:    fmt.Sprintf("%s_%s", c.UserId, TransIdPrefix),
+		To:      c.ParentId,
+	}
+
+	if err := b.NotifyEventStreamContext(ctx, payload, msg); err != nil {
+		return "", err
+	}
+
+	for {
+		select {
+		case event, ok := <-listener.Events:
+			if !ok {
+				return "", errors.WithMessage(errors.New("event listener was closed"), msg)
+			}
+			if url := stringProperty(event.Properties, "presignedFullFrameSnapshotUrl"); url != "" {
+				return url, nil
+			}
+		case <-b.disconnected():
+			err := errors.New("event stream was closed before the snapshot was available")
+			return "", errors.WithMessage(err, msg)
+		case <-ctx.Done():
+			return "", errors.WithMessage(ctx.Err(), msg)
 		}
-		return b.makeEventStreamRequest(payload, msg)
-	*/
-	/*
-		def callback(self, event):
-			if event.get("from") == basestation.get("deviceId") and event.get("resource") == "cameras/"+camera.get("deviceId") and event.get("action") == "fullFrameSnapshotAvailable":
-				return event.get("properties", {}).get("presignedFullFrameSnapshotUrl")
-			return None
-	*/
+	}
+}
+
+// DownloadFullFrameSnapshot triggers a full-frame snapshot and writes the JPEG image to w.
+// The presignedFullFrameSnapshotUrl url of the image is returned.
+func (c *Camera) DownloadFullFrameSnapshot(w io.Writer) (url string, err error) {
+	return c.DownloadFullFrameSnapshotContext(context.Background(), w)
+}
+
+// DownloadFullFrameSnapshotContext is like DownloadFullFrameSnapshot, but gives up when ctx is done.
+func (c *Camera) DownloadFullFrameSnapshotContext(ctx context.Context, w io.Writer) (url string, err error) {
+	url, err = c.TriggerFullFrameSnapshotContext(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.arlo.download(ctx, url, w); err != nil {
+		return "", errors.WithMessage(err, "failed to download full-frame snapshot")
+	}
+
+	return url, nil
 }
 
 // StartRecording causes the camera to start recording and returns a url that you must start reading from using ffmpeg
 // or something similar.
 func (c *Camera) StartRecording() (url string, err error) {
+	return c.StartRecordingContext(context.Background())
+}
+
+// StartRecordingContext is like StartRecording, but uses ctx for the request.
+func (c *Camera) StartRecordingContext(ctx context.Context) (url string, err error) {
 	msg := "failed to start recording"
 
-	url, err = c.StartStream()
+	url, err = c.StartStreamContext(ctx)
 	if err != nil {
 		return "", errors.WithMessage(err, msg)
 	}
 
-	body := map[string]string{"deviceId": c.DeviceId, "parentId": c.ParentId, "xcloudId": c.XCloudId, "olsonTimeZone": c.Properties.OlsonTimeZone}
-	resp, err := c.arlo.post(StartRecordUri, c.XCloudId, body, nil)
+	body := map[string]string{"deviceId": c.DeviceId, "parentId": c.ParentId, "xcloudId": c.XCloudId, "olsonTimeZone": (*Device)(c).Snapshot().Properties.OlsonTimeZone}
+	resp, err := c.arlo.post(ctx, StartRecordUri, c.XCloudId, body, nil)
 	if err := checkRequest(resp, err, msg); err != nil {
 		return "", errors.WithMessage(err, msg)
 	}
@@ -564,10 +804,15 @@ func (c *Camera) StartRecording() (url string, err error) {
 
 // StopRecording causes the camera to stop recording.
 func (c *Camera) StopRecording() error {
+	return c.StopRecordingContext(context.Background())
+}
+
+// StopRecordingContext is like StopRecording, but uses ctx for the request.
+func (c *Camera) StopRecordingContext(ctx context.Context) error {
 	msg := "failed to stop recording"
 
-	body := map[string]string{"deviceId": c.DeviceId, "parentId": c.ParentId, "xcloudId": c.XCloudId, "olsonTimeZone": c.Properties.OlsonTimeZone}
-	resp, err := c.arlo.post(StopRecordUri, c.XCloudId, body, nil)
+	body := map[string]string{"deviceId": c.DeviceId, "parentId": c.ParentId, "xcloudId": c.XCloudId, "olsonTimeZone": (*Device)(c).Snapshot().Properties.OlsonTimeZone}
+	resp, err := c.arlo.post(ctx, StopRecordUri, c.XCloudId, body, nil)
 	if err := checkRequest(resp, err, msg); err != nil {
 		return errors.WithMessage(err, msg)
 	}
@@ -577,9 +822,14 @@ func (c *Camera) StopRecording() error {
 
 // This function downloads a Cvr Playlist file for the period fromDate to toDate.
 func (c *Camera) GetCvrPlaylist(fromDate, toDate time.Time) (playlist *CvrPlaylist, err error) {
+	return c.GetCvrPlaylistContext(context.Background(), fromDate, toDate)
+}
+
+// GetCvrPlaylistContext is like GetCvrPlaylist, but uses ctx for the request.
+func (c *Camera) GetCvrPlaylistContext(ctx context.Context, fromDate, toDate time.Time) (playlist *CvrPlaylist, err error) {
 	msg := "failed to get cvr playlist"
 
-	resp, err := c.arlo.get(fmt.Sprintf(PlaylistUri, c.UniqueId, fromDate.Format("20060102"), toDate.Format("20060102")), c.XCloudId, nil)
+	resp, err := c.arlo.get(ctx, fmt.Sprintf(PlaylistUri, c.UniqueId, fromDate.Format("20060102"), toDate.Format("20060102")), c.XCloudId, nil)
 
 	if err != nil {
 		return nil, errors.WithMessage(err, msg)
@@ -592,7 +842,7 @@ func (c *Camera) GetCvrPlaylist(fromDate, toDate time.Time) (playlist *CvrPlayli
 	}
 
 	if !response.Success {
-		return nil, errors.New(msg)
+		return nil, errors.WithMessage(statusError(resp, response.Status), msg)
 	}
 
 	return &response.Data, nil
@@ -0,0 +1,283 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// The features that the methods of the devices check for, before they send a request. They're the names of the
+// capabilities in the capabilities document of the device's model, which are matched without regard to case.
+const (
+	FeatureAudioPlayback = "audioPlayback" // The speaker of the Arlo Baby: volume, playlist and sleep timer.
+	FeatureNightLight    = "nightLight"
+	FeaturePushToTalk    = "pushToTalk"
+	FeatureSiren         = "siren"
+)
+
+// ErrUnsupported is returned, wrapped in an UnsupportedError, by the methods of a device whose model doesn't support
+// the feature they need. Use errors.Is to check for it.
+var ErrUnsupported = errors.New("feature not supported by device")
+
+// UnsupportedError is returned by the methods of a device whose model doesn't support the feature they need.
+type UnsupportedError struct {
+	DeviceId string
+	ModelId  string
+	Feature  string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s not supported by device (%s) model (%s)", e.Feature, e.DeviceId, e.ModelId)
+}
+
+// Is makes errors.Is(err, ErrUnsupported) true for an UnsupportedError.
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// Capabilities is the capabilities document of a device model, which lists the features it has.
+type Capabilities struct {
+	ModelId string
+	Raw     json.RawMessage // The document as it was returned by the Arlo API.
+
+	features map[string]bool // By lowercase name.
+}
+
+func newCapabilities(modelId string, raw json.RawMessage) (*Capabilities, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, errors.Wrap(err, "failed to parse capabilities")
+	}
+
+	c := &Capabilities{ModelId: modelId, Raw: raw, features: make(map[string]bool)}
+
+	// The document has an entry for the model, which has its Capabilities. Some documents have them at the top level.
+	entry := document
+	for k, v := range document {
+		if strings.EqualFold(k, modelId) {
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil, errors.Wrap(err, "failed to parse capabilities")
+			}
+			break
+		}
+	}
+	for k, v := range entry {
+		if strings.EqualFold(k, "Capabilities") {
+			if err := c.add(v); err != nil {
+				return nil, errors.Wrap(err, "failed to parse capabilities")
+			}
+		}
+	}
+	return c, nil
+}
+
+// add collects the features in the Capabilities of a model. They're either a list of names, or entries by name, or a
+// list of those. Only the names of the entries are features, not the keys nested in them.
+func (c *Capabilities) add(raw json.RawMessage) error {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			c.features[strings.ToLower(name)] = supported(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			switch value := value.(type) {
+			case string:
+				c.features[strings.ToLower(value)] = true
+			case map[string]interface{}:
+				for name, value := range value {
+					c.features[strings.ToLower(name)] = supported(value)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// supported reports whether the entry of a capability means the model has it. An entry is either an object with a
+// "supported" flag, or a value that isn't false, null or zero. An object without the flag, e.g. the range of a setting,
+// is supported.
+func supported(value interface{}) bool {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for k, v := range value {
+			if strings.EqualFold(k, "supported") {
+				return supported(v)
+			}
+		}
+		return true
+	case bool:
+		return value
+	case float64:
+		return value != 0
+	case string:
+		return value != ""
+	case nil:
+		return false
+	}
+	return true
+}
+
+// Has reports whether the model has the feature.
+func (c *Capabilities) Has(feature string) bool {
+	return c.features[strings.ToLower(feature)]
+}
+
+// Features returns the names of the features of the model, in lowercase.
+func (c *Capabilities) Features() []string {
+	features := make([]string, 0, len(c.features))
+	for f, ok := range c.features {
+		if ok {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// A capabilitiesFetch is a request for the capabilities of a model that's in flight. Callers that need them at the
+// same time wait for it, rather than each making their own request.
+type capabilitiesFetch struct {
+	done chan struct{} // Closed when the request is done.
+	c    *Capabilities
+	err  error
+}
+
+// capabilities returns the capabilities of the model, which are fetched the first time they're needed and cached.
+// If the Arlo API doesn't have a capabilities document for the model, that's cached too, and ok is false.
+func (a *Arlo) capabilities(ctx context.Context, modelId string) (c *Capabilities, ok bool, err error) {
+	for {
+		a.capabilitiesMutex.Lock()
+		if c, cached := a.capabilitiesCache[modelId]; cached {
+			a.capabilitiesMutex.Unlock()
+			return c, c != nil, nil
+		}
+
+		f, fetching := a.capabilitiesFetches[modelId]
+		if !fetching {
+			f = &capabilitiesFetch{done: make(chan struct{})}
+			a.capabilitiesFetches[modelId] = f
+			a.capabilitiesMutex.Unlock()
+
+			f.c, f.err = a.fetchCapabilities(ctx, modelId)
+
+			a.capabilitiesMutex.Lock()
+			if f.err == nil {
+				a.capabilitiesCache[modelId] = f.c
+			}
+			delete(a.capabilitiesFetches, modelId)
+			a.capabilitiesMutex.Unlock()
+			close(f.done)
+
+			return f.c, f.c != nil, f.err
+		}
+		a.capabilitiesMutex.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, false, errors.WithMessage(ctx.Err(), fmt.Sprintf("failed to get capabilities for model (%s)", modelId))
+		}
+
+		// The request was made with the ctx of another caller, so if that's why it failed, make it again with this one.
+		if errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded) {
+			continue
+		}
+		return f.c, f.c != nil, f.err
+	}
+}
+
+// fetchCapabilities gets the capabilities of the model from the Arlo API. It returns nil, and no error, if the Arlo API
+// doesn't have a capabilities document for the model.
+func (a *Arlo) fetchCapabilities(ctx context.Context, modelId string) (c *Capabilities, err error) {
+	msg := fmt.Sprintf("failed to get capabilities for model (%s)", modelId)
+
+	resp, err := a.get(ctx, fmt.Sprintf(CapabilitiesUri, modelId, time.Now().UnixNano()/int64(time.Millisecond)), "", nil)
+	if err != nil {
+		// A model without a document is remembered, so it isn't asked for again; any other failure might be temporary.
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 && !IsUnauthorized(err) && !IsRateLimited(err) {
+			return nil, nil
+		}
+		return nil, errors.WithMessage(err, msg)
+	}
+	defer resp.Body.Close()
+
+	var raw json.RawMessage
+	if err := resp.Decode(&raw); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	if c, err = newCapabilities(modelId, raw); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	return c, nil
+}
+
+// Capabilities returns the capabilities of the device's model. It returns nil if the Arlo API doesn't have them.
+func (d *Device) Capabilities() (*Capabilities, error) {
+	return d.CapabilitiesContext(context.Background())
+}
+
+// CapabilitiesContext is like Capabilities, but uses ctx for the request.
+func (d *Device) CapabilitiesContext(ctx context.Context) (*Capabilities, error) {
+	c, _, err := d.arlo.capabilities(ctx, d.ModelId)
+	return c, err
+}
+
+// Supports reports whether the device's model is known to support the feature. It's false if the capabilities of the
+// model can't be found.
+func (d *Device) Supports(feature string) bool {
+	c, ok, err := d.arlo.capabilities(context.Background(), d.ModelId)
+	return err == nil && ok && c.Has(feature)
+}
+
+// require returns an UnsupportedError if the device's model is known not to support the feature. When the capabilities
+// of the model can't be found, the request is sent anyway, and it's up to the device.
+func (d *Device) require(ctx context.Context, feature string) error {
+	c, ok, err := d.arlo.capabilities(ctx, d.ModelId)
+	if err != nil || !ok || c.Has(feature) {
+		return nil
+	}
+	return &UnsupportedError{DeviceId: d.DeviceId, ModelId: d.ModelId, Feature: feature}
+}
+
+// Capabilities returns the capabilities of the camera's model. It returns nil if the Arlo API doesn't have them.
+func (c *Camera) Capabilities() (*Capabilities, error) {
+	return (*Device)(c).CapabilitiesContext(context.Background())
+}
+
+// CapabilitiesContext is like Capabilities, but uses ctx for the request.
+func (c *Camera) CapabilitiesContext(ctx context.Context) (*Capabilities, error) {
+	return (*Device)(c).CapabilitiesContext(ctx)
+}
+
+// Supports reports whether the camera's model is known to support the feature.
+func (c *Camera) Supports(feature string) bool {
+	return (*Device)(c).Supports(feature)
+}
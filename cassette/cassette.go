@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+// Package cassette records the http traffic of an Arlo client to a file, and replays it later without the network.
+//
+// A Recorder and a Replayer are both http.RoundTrippers, so they plug into the client with arlo.WithTransport, which
+// covers the api requests, the event stream and downloads:
+//
+//	rec := cassette.NewRecorder("testdata/login.json", nil)
+//	a, _ := arlo.NewClient(arlo.WithTransport(rec))
+//	err := a.Login(user, pass)
+//	...
+//	a.Logout()
+//	err = rec.Close()
+//
+//	rep, _ := cassette.NewReplayer("testdata/login.json", true)
+//	a, _ := arlo.NewClient(arlo.WithTransport(rep))
+//	err := a.Login("anyone@example.com", "anything")
+//
+// Cassettes are sanitized when they're saved: auth tokens, email addresses, passwords, user and device ids and presigned
+// urls are replaced with placeholders everywhere they appear. Replayed requests are matched by method, url and body,
+// ignoring the things that change between runs (the email and password, transIds and the "t" query parameter).
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+)
+
+// A Cassette is a recording of http traffic.
+type Cassette struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// An Interaction is a request and the response it got.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// BodyEncoding is "base64" if the body isn't text, e.g. a downloaded video.
+	BodyEncoding string `json:"bodyEncoding,omitempty"`
+	// Events are the server-sent events of an event stream response, in the order they arrived.
+	Events []string `json:"events,omitempty"`
+}
+
+// Load reads a cassette from the file at path.
+func Load(path string) (*Cassette, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Save writes the cassette to the file at path, which only the current user can read.
+func (c *Cassette) Save(path string) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0600)
+}
+
+func isEventStream(header http.Header) bool {
+	return header.Get("Content-Type") == "text/event-stream"
+}
+
+var transIdPattern = regexp.MustCompile(`"transId"\s*:\s*"([^"]*)"`)
+
+// transId returns the transId in a request body or event, or "" if it doesn't have one.
+func transId(s string) string {
+	if m := transIdPattern.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// key returns what a request is matched on when it's replayed.
+func key(method string, rawurl string, body string) string {
+	return method + " " + normalizeURL(rawurl) + " " + normalizeBody(body)
+}
+
+// normalizeURL drops the "t" query parameter, which is a timestamp, and puts the rest of the query in a stable order.
+func normalizeURL(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+	q := u.Query()
+	q.Del("t")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// normalizeBody drops the parts of a JSON body that change between runs: the transId, and the credentials used to log in.
+func normalizeBody(body string) string {
+	var v interface{}
+	if body == "" || json.Unmarshal([]byte(body), &v) != nil {
+		return body
+	}
+
+	if m, ok := v.(map[string]interface{}); ok {
+		delete(m, "transId")
+		for _, k := range []string{"email", "password"} {
+			if _, ok := m[k]; ok {
+				m[k] = ""
+			}
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return string(b)
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cassette_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	arlo "github.com/jeffreydwalter/arlo-go"
+	"github.com/jeffreydwalter/arlo-go/arlotest"
+	"github.com/jeffreydwalter/arlo-go/cassette"
+)
+
+// session logs in, sets and gets the mode of the basestation, and logs out.
+func session(t *testing.T, a *arlo.Arlo) {
+	if err := a.Login(arlotest.DefaultUser, arlotest.DefaultPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	b := a.Basestations.All()[0]
+	if _, err := b.SetCustomMode("mode2"); err != nil {
+		t.Fatal(err)
+	}
+
+	modes, err := b.GetModes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modes.Active != "mode2" {
+		t.Errorf("GetModes() active = %q, want mode2", modes.Active)
+	}
+
+	if err := a.Logout(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "session.json")
+
+	srv := arlotest.NewServer()
+	srv.AddDevice(arlotest.Basestation("BASESTATION1"))
+	srv.AddDevice(arlotest.Camera("CAMERA1", "BASESTATION1"))
+	url := srv.URL
+
+	rec := cassette.NewRecorder(path, nil)
+	a, err := arlo.NewClient(arlo.WithBaseURL(url), arlo.WithTransport(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session(t, a)
+	srv.Close()
+
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"token-1", arlotest.DefaultUser, arlotest.DefaultUserId, "BASESTATION1", "CAMERA1", `"password":"password"`} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("cassette contains %q", secret)
+		}
+	}
+
+	// The server is gone, so everything has to come from the cassette.
+	rep, err := cassette.NewReplayer(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rep.Close()
+
+	a, err = arlo.NewClient(arlo.WithBaseURL(url), arlo.WithTransport(rep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	session(t, a)
+
+	if n := rep.Unused(); n != 0 {
+		t.Errorf("%d recorded interactions weren't replayed", n)
+	}
+}
+
+func TestReplayStrict(t *testing.T) {
+	rep := cassette.NewCassetteReplayer(&cassette.Cassette{}, true)
+	a, err := arlo.NewClient(arlo.WithTransport(rep))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Login(arlotest.DefaultUser, arlotest.DefaultPassword); !errors.Is(err, cassette.ErrUnmatched) {
+		t.Errorf("Login() = %v, want %v", err, cassette.ErrUnmatched)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// A Recorder is an http.RoundTripper that records the requests it makes, and their responses, to a cassette.
+type Recorder struct {
+	path      string
+	transport http.RoundTripper
+
+	mutex    sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder that makes the requests with transport, or http.DefaultTransport if it's nil, and saves
+// them to the file at path when it's closed.
+func NewRecorder(path string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{path: path, transport: transport}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		// Failed requests aren't recorded, since there's no response to replay.
+		return nil, err
+	}
+
+	i := &Interaction{
+		Request: Request{Method: req.Method, URL: req.URL.String(), Body: string(body)},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+		},
+	}
+	// The cookies are secrets, and aren't needed to replay the responses.
+	i.Response.Header.Del("Set-Cookie")
+	// Redacting the body changes its length.
+	i.Response.Header.Del("Content-Length")
+
+	r.mutex.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mutex.Unlock()
+
+	// The event stream doesn't end, so its events are recorded as they're read.
+	if isEventStream(resp.Header) {
+		resp.Body = &eventRecorder{ReadCloser: resp.Body, recorder: r, interaction: i}
+		return resp, nil
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	r.mutex.Lock()
+	if utf8.Valid(b) {
+		i.Response.Body = string(b)
+	} else {
+		i.Response.Body = base64.StdEncoding.EncodeToString(b)
+		i.Response.BodyEncoding = "base64"
+	}
+	r.mutex.Unlock()
+
+	return resp, nil
+}
+
+// Cassette returns a copy of what's been recorded so far, before it's redacted.
+func (r *Recorder) Cassette() *Cassette {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c := &Cassette{}
+	for _, i := range r.cassette.Interactions {
+		copied := *i
+		copied.Response.Header = i.Response.Header.Clone()
+		copied.Response.Events = append([]string(nil), i.Response.Events...)
+		c.Interactions = append(c.Interactions, &copied)
+	}
+	return c
+}
+
+// Save redacts what's been recorded so far, and writes it to the file.
+func (r *Recorder) Save() error {
+	c := r.Cassette()
+	c.Redact()
+	if err := c.Save(r.path); err != nil {
+		return errors.Wrap(err, "failed to save cassette")
+	}
+	return nil
+}
+
+// Close saves the cassette. Event streams that are still open are saved with the events read so far.
+func (r *Recorder) Close() error {
+	return r.Save()
+}
+
+// An eventRecorder records the events of an event stream as they're read.
+type eventRecorder struct {
+	io.ReadCloser
+	recorder    *Recorder
+	interaction *Interaction
+	buf         bytes.Buffer
+}
+
+func (e *eventRecorder) Read(p []byte) (int, error) {
+	n, err := e.ReadCloser.Read(p)
+	e.buf.Write(p[:n])
+
+	// Events end with a blank line.
+	for {
+		s := strings.Replace(e.buf.String(), "\r\n", "\n", -1)
+		end := strings.Index(s, "\n\n")
+		if end < 0 {
+			break
+		}
+
+		e.recorder.mutex.Lock()
+		e.interaction.Response.Events = append(e.interaction.Response.Events, s[:end])
+		e.recorder.mutex.Unlock()
+
+		e.buf.Reset()
+		e.buf.WriteString(s[end+2:])
+	}
+
+	return n, err
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// sensitive maps the JSON keys whose values are redacted to the kind of placeholder they're replaced with.
+// Keys that name the same thing (e.g. deviceId and parentId) share a kind, so the same value gets the same placeholder.
+// Passwords aren't in here, since they're only replaced where they're the value of a "password" key.
+var sensitive = map[string]string{
+	"token":                         "token",
+	"email":                         "email",
+	"userId":                        "user",
+	"ownerId":                       "user",
+	"deviceId":                      "device",
+	"parentId":                      "device",
+	"uniqueId":                      "unique",
+	"xCloudId":                      "xcloud",
+	"serialNumber":                  "serial",
+	"paymentId":                     "payment",
+	"url":                           "url",
+	"presignedContentUrl":           "url",
+	"presignedThumbnailUrl":         "url",
+	"presignedLastImageUrl":         "url",
+	"presignedSnapshotUrl":          "url",
+	"presignedFullFrameSnapshotUrl": "url",
+}
+
+// minSecretLength keeps short values, which could be anything, from being replaced everywhere they appear.
+const minSecretLength = 4
+
+// A redactor replaces secrets with placeholders. It learns the secrets from the values of the sensitive keys of the
+// JSON it's shown, and then replaces them everywhere, including inside other values like urls and transIds.
+type redactor struct {
+	secrets map[string]string // Placeholder by secret.
+	counts  map[string]int    // Number of placeholders made, by kind.
+}
+
+func newRedactor() *redactor {
+	return &redactor{secrets: make(map[string]string), counts: make(map[string]int)}
+}
+
+func (r *redactor) placeholder(kind string) string {
+	r.counts[kind]++
+	n := r.counts[kind]
+	switch kind {
+	case "email":
+		return fmt.Sprintf("user%d@example.com", n)
+	case "url":
+		return fmt.Sprintf("https://example.com/redacted/%d", n)
+	default:
+		return fmt.Sprintf("REDACTED-%s-%d", strings.ToUpper(kind), n)
+	}
+}
+
+// learn finds the secrets in a JSON document. Anything that isn't JSON is ignored.
+func (r *redactor) learn(s string) {
+	var v interface{}
+	if json.Unmarshal([]byte(s), &v) == nil {
+		r.walk(v)
+	}
+}
+
+func (r *redactor) walk(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if s, ok := value.(string); ok {
+				if kind, ok := sensitive[k]; ok && len(s) >= minSecretLength {
+					if _, ok := r.secrets[s]; !ok {
+						r.secrets[s] = r.placeholder(kind)
+					}
+				}
+				continue
+			}
+			r.walk(value)
+		}
+	case []interface{}:
+		for _, value := range v {
+			r.walk(value)
+		}
+	}
+}
+
+// replacer returns a replacer of the secrets learned so far. The longest secrets are replaced first, so a secret that
+// contains another one (e.g. a unique id, which is made of the user id and the device id) keeps its own placeholder.
+func (r *redactor) replacer() *strings.Replacer {
+	secrets := make([]string, 0, len(r.secrets))
+	for s := range r.secrets {
+		secrets = append(secrets, s)
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+
+	var pairs []string
+	for _, s := range secrets {
+		pairs = append(pairs, s, r.secrets[s])
+		// Secrets also show up escaped in urls, e.g. the token of the event stream.
+		if escaped := url.QueryEscape(s); escaped != s {
+			pairs = append(pairs, escaped, url.QueryEscape(r.secrets[s]))
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// redact replaces the secrets in s. JSON is decoded first, so secrets that were escaped in it are found too.
+func redact(replacer *strings.Replacer, s string) string {
+	var v interface{}
+	if s == "" || json.Unmarshal([]byte(s), &v) != nil {
+		return replacer.Replace(s)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactValue(replacer, v)); err != nil {
+		return replacer.Replace(s)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func redactValue(replacer *strings.Replacer, v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return replacer.Replace(v)
+	case map[string]interface{}:
+		for k, value := range v {
+			if _, ok := value.(string); ok && k == "password" {
+				v[k] = "REDACTED"
+				continue
+			}
+			v[k] = redactValue(replacer, value)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(replacer, v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// redactEvent replaces the secrets in the data of a server-sent event.
+func redactEvent(replacer *strings.Replacer, event string) string {
+	lines := strings.Split(event, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "data:") {
+			data := strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+			lines[i] = "data: " + redact(replacer, data)
+		} else {
+			lines[i] = replacer.Replace(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// eventData returns the data of a server-sent event.
+func eventData(event string) string {
+	var data []string
+	for _, line := range strings.Split(event, "\n") {
+		if strings.HasPrefix(line, "data:") {
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	return strings.Join(data, "\n")
+}
+
+// Redact replaces the secrets in every interaction of the cassette with placeholders.
+func (c *Cassette) Redact() {
+	r := newRedactor()
+	for _, i := range c.Interactions {
+		r.learn(i.Request.Body)
+		if i.Response.BodyEncoding == "" {
+			r.learn(i.Response.Body)
+		}
+		for _, event := range i.Response.Events {
+			r.learn(eventData(event))
+		}
+	}
+
+	replacer := r.replacer()
+	for _, i := range c.Interactions {
+		i.Request.URL = replacer.Replace(i.Request.URL)
+		i.Request.Body = redact(replacer, i.Request.Body)
+		if i.Response.BodyEncoding == "" {
+			i.Response.Body = redact(replacer, i.Response.Body)
+		}
+		for k, values := range i.Response.Header {
+			for j := range values {
+				i.Response.Header[k][j] = replacer.Replace(values[j])
+			}
+		}
+		for j := range i.Response.Events {
+			i.Response.Events[j] = redactEvent(replacer, i.Response.Events[j])
+		}
+	}
+}
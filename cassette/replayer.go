@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnmatched is returned by a strict Replayer for a request that isn't in the cassette.
+var ErrUnmatched = errors.New("cassette: no recorded interaction for request")
+
+// A Replayer is an http.RoundTripper that answers requests with the responses recorded in a cassette, without the network.
+//
+// Every recorded interaction is replayed once, in the order it was recorded. When a request has been made more times than
+// it was recorded, a strict Replayer fails it with ErrUnmatched, and a lenient one replays the last response again.
+// A request that was never recorded fails with ErrUnmatched in strict mode, and gets a 404 Not Found otherwise.
+//
+// The transIds of the event stream messages are different every time, so the events of a replayed event stream have
+// the transIds of the replayed requests, and each response is held back until its request has been made.
+type Replayer struct {
+	strict bool
+
+	mutex        sync.Mutex
+	interactions map[string][]*Interaction // By key, in the order they were recorded.
+	used         map[string]int
+	transIds     map[string]string // The transIds of the replayed requests, by the recorded transIds.
+	changed      chan struct{}     // Closed, and replaced, when a transId is added.
+	closed       chan struct{}
+	closeOnce    sync.Once
+}
+
+// NewReplayer returns a Replayer of the cassette in the file at path.
+func NewReplayer(path string, strict bool) (*Replayer, error) {
+	c, err := Load(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load cassette")
+	}
+	return NewCassetteReplayer(c, strict), nil
+}
+
+// NewCassetteReplayer returns a Replayer of a cassette that's already loaded.
+func NewCassetteReplayer(c *Cassette, strict bool) *Replayer {
+	r := &Replayer{
+		strict:       strict,
+		interactions: make(map[string][]*Interaction),
+		used:         make(map[string]int),
+		transIds:     make(map[string]string),
+		changed:      make(chan struct{}),
+		closed:       make(chan struct{}),
+	}
+
+	for _, i := range c.Interactions {
+		k := key(i.Request.Method, i.Request.URL, i.Request.Body)
+		r.interactions[k] = append(r.interactions[k], i)
+	}
+
+	return r
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+		req.Body.Close()
+	}
+
+	i, err := r.match(req.Method, req.URL.String(), string(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if i == nil {
+		return r.response(req, http.StatusNotFound, http.Header{"Content-Type": {"application/json"}},
+			ioutil.NopCloser(strings.NewReader(`{"success":false,"data":{"error":"404","reason":"not in cassette"}}`))), nil
+	}
+
+	if isEventStream(i.Response.Header) {
+		pr, pw := io.Pipe()
+		go r.stream(req, i.Response.Events, pw)
+		return r.response(req, i.Response.StatusCode, i.Response.Header, pr), nil
+	}
+
+	b := []byte(i.Response.Body)
+	if i.Response.BodyEncoding == "base64" {
+		if b, err = base64.StdEncoding.DecodeString(i.Response.Body); err != nil {
+			return nil, errors.Wrap(err, "failed to decode response body")
+		}
+	}
+
+	return r.response(req, i.Response.StatusCode, i.Response.Header, ioutil.NopCloser(bytes.NewReader(b))), nil
+}
+
+// match returns the next recorded interaction for the request, and remembers its transId.
+func (r *Replayer) match(method string, rawurl string, body string) (*Interaction, error) {
+	k := key(method, rawurl, body)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	interactions := r.interactions[k]
+	n := r.used[k]
+
+	var i *Interaction
+	switch {
+	case n < len(interactions):
+		i = interactions[n]
+		r.used[k]++
+	case r.strict && len(interactions) > 0:
+		return nil, errors.Wrapf(ErrUnmatched, "%s %s was made more than the %d times it was recorded", method, rawurl, len(interactions))
+	case r.strict:
+		return nil, errors.Wrapf(ErrUnmatched, "%s %s", method, rawurl)
+	case len(interactions) > 0:
+		i = interactions[len(interactions)-1]
+	default:
+		return nil, nil
+	}
+
+	if recorded, replayed := transId(i.Request.Body), transId(body); recorded != "" && replayed != "" {
+		r.transIds[recorded] = replayed
+		close(r.changed)
+		r.changed = make(chan struct{})
+	}
+
+	return i, nil
+}
+
+func (r *Replayer) response(req *http.Request, statusCode int, header http.Header, body io.ReadCloser) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          body,
+		ContentLength: -1,
+		Request:       req,
+	}
+}
+
+// stream writes the events of a replayed event stream to w. An event with a transId waits until the request it's the
+// response to has been replayed. Once all of the events are written, the stream stays open until it's closed.
+func (r *Replayer) stream(req *http.Request, events []string, w *io.PipeWriter) {
+	done := func(err error) {
+		w.CloseWithError(err)
+	}
+
+	for _, event := range events {
+		if recorded := transId(event); recorded != "" {
+			replayed, err := r.waitTransId(req, recorded)
+			if err != nil {
+				done(err)
+				return
+			}
+			event = strings.Replace(event, recorded, replayed, -1)
+		}
+
+		if _, err := io.WriteString(w, event+"\n\n"); err != nil {
+			return
+		}
+	}
+
+	select {
+	case <-req.Context().Done():
+		done(req.Context().Err())
+	case <-r.closed:
+		done(io.EOF)
+	}
+}
+
+// waitTransId waits for the request with the recorded transId to be replayed, and returns its transId.
+func (r *Replayer) waitTransId(req *http.Request, recorded string) (string, error) {
+	for {
+		r.mutex.Lock()
+		replayed, ok := r.transIds[recorded]
+		changed := r.changed
+		r.mutex.Unlock()
+
+		if ok {
+			return replayed, nil
+		}
+
+		select {
+		case <-changed:
+		case <-req.Context().Done():
+			return "", req.Context().Err()
+		case <-r.closed:
+			return "", io.EOF
+		}
+	}
+}
+
+// Unused returns how many recorded interactions haven't been replayed.
+func (r *Replayer) Unused() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	n := 0
+	for k, interactions := range r.interactions {
+		if used := r.used[k]; used < len(interactions) {
+			n += len(interactions) - used
+		}
+	}
+	return n
+}
+
+// Close ends the replayed event streams.
+func (r *Replayer) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+	})
+	return nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"fmt"
+)
+
+// A Chime is a Device of type "chime", which rings when a doorbell that's paired with it is pressed.
+type Chime Device
+
+// Chimes is a slice of Chime objects.
+type Chimes []Chime
+
+// Find returns a chime with the device id passed in.
+func (cs *Chimes) Find(deviceId string) *Chime {
+	for i := range *cs {
+		if (*cs)[i].DeviceId == deviceId {
+			return &(*cs)[i]
+		}
+	}
+
+	return nil
+}
+
+// ChimeCollection is the chimes of the account. It's a view of the devices, like DeviceCollection.
+type ChimeCollection struct {
+	view
+}
+
+var chimeKinds = []DeviceKind{KindChime}
+
+// All returns the chimes, in the order the Arlo API returned them.
+func (cs ChimeCollection) All() []*Chime {
+	return chimes(cs.list(nil))
+}
+
+// Range calls fn for each chime, in order, until it returns false. See DeviceCollection.Range.
+func (cs ChimeCollection) Range(fn func(c *Chime) bool) {
+	for _, c := range cs.All() {
+		if !fn(c) {
+			return
+		}
+	}
+}
+
+// Find returns the chime with the device id, or nil if there isn't one.
+func (cs ChimeCollection) Find(deviceId string) *Chime {
+	c, _ := cs.findId(deviceId).(*Chime)
+	return c
+}
+
+// FindByName returns the first chime with the name, or nil if there isn't one.
+func (cs ChimeCollection) FindByName(name string) *Chime {
+	c, _ := cs.find(byName(name)).(*Chime)
+	return c
+}
+
+// FindByUniqueId returns the chime with the unique id, or nil if there isn't one.
+func (cs ChimeCollection) FindByUniqueId(uniqueId string) *Chime {
+	c, _ := cs.find(byUniqueId(uniqueId)).(*Chime)
+	return c
+}
+
+// FindByModel returns the chimes of the model.
+func (cs ChimeCollection) FindByModel(modelId string) []*Chime {
+	return chimes(cs.list(byModel(modelId)))
+}
+
+func chimes(vs []interface{}) []*Chime {
+	var chimes []*Chime
+	for _, c := range vs {
+		chimes = append(chimes, c.(*Chime))
+	}
+	return chimes
+}
+
+func (c *Chime) resource() string {
+	return fmt.Sprintf("chimes/%s", c.DeviceId)
+}
+
+// GetState returns the current state of the chime.
+func (c *Chime) GetState() (response *EventStreamResponse, err error) {
+	return c.GetStateContext(context.Background())
+}
+
+// GetStateContext is like GetState, but uses ctx for the request.
+func (c *Chime) GetStateContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return (*Device)(c).getState(ctx, c.ParentId, c.resource(), "failed to get chime state")
+}
+
+// Events returns a listener for the events of the chime. Call Unsubscribe() on the listener when you're done.
+func (c *Chime) Events() *EventListener {
+	return c.arlo.listeners.add(EventFilter{Resource: c.resource()}, c.ParentId)
+}
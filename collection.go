@@ -0,0 +1,277 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"reflect"
+	"sync"
+)
+
+// A collection holds the devices of the account, wrapped in the types of their kinds. It's shared by the collections on
+// the Arlo object, each of which is a view of the devices of its kinds, and it's safe to use while GetDevices refreshes it.
+//
+// Each device has one wrapper, which keeps its pointer across refreshes. A refresh updates its fields in place while it
+// holds the write lock, so they're read under the read lock (see Device.Snapshot). The fields that the methods of the
+// devices read without the lock (see identity) are never updated in place: a device whose identity changes is replaced.
+type collection struct {
+	rwmutex sync.RWMutex
+	devices []interface{}          // In the order the Arlo API returned them.
+	byId    map[string]interface{} // By device id.
+}
+
+func newCollection() *collection {
+	return &collection{byId: make(map[string]interface{})}
+}
+
+// deviceOf returns the device that a value returned by Device.Wrap wraps.
+func deviceOf(v interface{}) *Device {
+	switch v := v.(type) {
+	case *ArloQ:
+		return &v.Basestation.Device
+	case *Basestation:
+		return &v.Device
+	case *Bridge:
+		return &v.Device
+	case *Camera:
+		return (*Device)(v)
+	case *Chime:
+		return (*Device)(v)
+	case *Doorbell:
+		return (*Device)(v)
+	case *Light:
+		return (*Device)(v)
+	case *Siren:
+		return (*Device)(v)
+	case *Device:
+		return v
+	}
+	return nil
+}
+
+// kindOf returns the kind of a value returned by Device.Wrap.
+func kindOf(v interface{}) DeviceKind {
+	switch v.(type) {
+	case *ArloQ:
+		return KindArloQ
+	case *Basestation:
+		return KindBasestation
+	case *Bridge:
+		return KindBridge
+	case *Camera:
+		return KindCamera
+	case *Chime:
+		return KindChime
+	case *Doorbell:
+		return KindDoorbell
+	case *Light:
+		return KindLight
+	case *Siren:
+		return KindSiren
+	}
+	return KindUnknown
+}
+
+// replace makes the devices the contents of the collection, and returns how they differ from what was in it.
+// The devices that were already in it keep their pointers, unless their identity changed.
+func (c *collection) replace(devices Devices) []DeviceEvent {
+	c.rwmutex.Lock()
+	defer c.rwmutex.Unlock()
+
+	var events []DeviceEvent
+	wrapped := make([]interface{}, 0, len(devices))
+	byId := make(map[string]interface{}, len(devices))
+	for _, d := range devices {
+		v, ok := c.byId[d.DeviceId]
+		if !ok {
+			events = append(events, DeviceEvent{Type: DeviceAdded, Device: d})
+		} else if changes := diff(*deviceOf(v), d); len(changes) > 0 {
+			events = append(events, DeviceEvent{Type: DeviceChanged, Device: d, Previous: *deviceOf(v), Changes: changes})
+		}
+
+		switch {
+		case !ok || kindOf(v) != d.Kind() || identity(*deviceOf(v)) != identity(d):
+			v = d.Wrap()
+		case !reflect.DeepEqual(*deviceOf(v), d):
+			update(v, d)
+		}
+		wrapped = append(wrapped, v)
+		byId[d.DeviceId] = v
+	}
+
+	for _, v := range c.devices {
+		if d := deviceOf(v); byId[d.DeviceId] == nil {
+			events = append(events, DeviceEvent{Type: DeviceRemoved, Device: *d})
+		}
+	}
+
+	c.devices, c.byId = wrapped, byId
+	return events
+}
+
+// deviceIdentity is the fields of a device that its methods read without the lock of the collection, e.g. to address
+// requests to it. They're not expected to change, but if they do, the device is replaced rather than updated.
+type deviceIdentity struct {
+	DeviceId, DeviceType, ParentId, UserId, XCloudId, UniqueId, ModelId string
+}
+
+func identity(d Device) deviceIdentity {
+	return deviceIdentity{d.DeviceId, d.DeviceType, d.ParentId, d.UserId, d.XCloudId, d.UniqueId, d.ModelId}
+}
+
+// update sets the fields of the device that v, returned by Device.Wrap, wraps. The fields of its identity are left
+// alone, since they may be read without the lock; replace only updates a device whose identity is the same.
+// The caller must hold the write lock.
+func update(v interface{}, d Device) {
+	set(deviceOf(v), d)
+	// An Arlo Q is both a basestation and a camera, each with its own copy of the device.
+	if q, ok := v.(*ArloQ); ok {
+		set((*Device)(&q.Camera), d)
+	}
+}
+
+func set(dst *Device, d Device) {
+	to, from := reflect.ValueOf(dst).Elem(), reflect.ValueOf(d)
+	identity := reflect.TypeOf(deviceIdentity{})
+	for i := 0; i < to.NumField(); i++ {
+		f := to.Type().Field(i)
+		if _, ok := identity.FieldByName(f.Name); f.PkgPath != "" || ok {
+			continue
+		}
+		to.Field(i).Set(from.Field(i))
+	}
+}
+
+// snapshot returns a copy of the device, read under the lock, since a refresh may be updating it.
+func (c *collection) snapshot(d *Device) Device {
+	if c == nil {
+		return *d
+	}
+
+	c.rwmutex.RLock()
+	defer c.rwmutex.RUnlock()
+	return *d
+}
+
+// find returns the first device of one of the kinds that match returns true for, or nil if there isn't one.
+// A nil collection is empty.
+func (c *collection) find(kinds []DeviceKind, match func(d *Device) bool) interface{} {
+	if c == nil {
+		return nil
+	}
+
+	c.rwmutex.RLock()
+	defer c.rwmutex.RUnlock()
+
+	for _, v := range c.devices {
+		if isKind(v, kinds) && match(deviceOf(v)) {
+			return v
+		}
+	}
+	return nil
+}
+
+// findId is like find, but looks the device up by its id.
+func (c *collection) findId(kinds []DeviceKind, deviceId string) interface{} {
+	if c == nil {
+		return nil
+	}
+
+	c.rwmutex.RLock()
+	defer c.rwmutex.RUnlock()
+
+	if v, ok := c.byId[deviceId]; ok && isKind(v, kinds) {
+		return v
+	}
+	return nil
+}
+
+// filter returns the devices of the kinds that match returns true for. A nil match returns all of them.
+func (c *collection) filter(kinds []DeviceKind, match func(d *Device) bool) []interface{} {
+	if c == nil {
+		return nil
+	}
+
+	c.rwmutex.RLock()
+	defer c.rwmutex.RUnlock()
+
+	var devices []interface{}
+	for _, v := range c.devices {
+		if isKind(v, kinds) && (match == nil || match(deviceOf(v))) {
+			devices = append(devices, v)
+		}
+	}
+	return devices
+}
+
+// isKind reports whether v is of one of the kinds. No kinds means any kind.
+func isKind(v interface{}, kinds []DeviceKind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, kind := range kinds {
+		if kindOf(v) == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// A view is the devices of some kinds in a collection. It's what the collections on the Arlo object have in common;
+// each of them adds the methods that return the devices as their kind. See DeviceCollection.
+type view struct {
+	devices *collection
+	kinds   []DeviceKind // No kinds means every kind.
+}
+
+// Len returns the number of devices.
+func (v view) Len() int {
+	return len(v.devices.filter(v.kinds, nil))
+}
+
+// Snapshot returns a copy of the device with the device id, which is safe to read while the devices are being
+// refreshed. ok is false if there isn't one.
+func (v view) Snapshot(deviceId string) (device Device, ok bool) {
+	d := deviceOf(v.findId(deviceId))
+	if d == nil {
+		return Device{}, false
+	}
+	return v.devices.snapshot(d), true
+}
+
+func (v view) find(match func(d *Device) bool) interface{} {
+	return v.devices.find(v.kinds, match)
+}
+
+func (v view) findId(deviceId string) interface{} {
+	return v.devices.findId(v.kinds, deviceId)
+}
+
+func (v view) list(match func(d *Device) bool) []interface{} {
+	return v.devices.filter(v.kinds, match)
+}
+
+func byName(name string) func(d *Device) bool {
+	return func(d *Device) bool { return d.DeviceName == name }
+}
+
+func byUniqueId(uniqueId string) func(d *Device) bool {
+	return func(d *Device) bool { return d.UniqueId == uniqueId }
+}
+
+func byModel(modelId string) func(d *Device) bool {
+	return func(d *Device) bool { return d.ModelId == modelId }
+}
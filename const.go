@@ -18,14 +18,29 @@ package arlo
 
 const (
 	DeviceTypeArloQ       = "arloq"
+	DeviceTypeArloQs      = "arloqs"
 	DeviceTypeArloBridge  = "arlobridge"
 	DeviceTypeBasestation = "basestation"
 	DeviceTypeCamera      = "camera"
+	DeviceTypeChime       = "chime"
+	DeviceTypeDoorbell    = "doorbell"
 	DeviceTypeLights      = "lights"
 	DeviceTypeSiren       = "siren"
 
 	TransIdPrefix = "web"
 	BaseUrl       = "https://my.arlo.com/hmsweb"
+	AuthBaseUrl   = "https://ocapi-app.arlo.com/api"
+
+	FactorTypeEmail = "EMAIL"
+	FactorTypePush  = "PUSH"
+	FactorTypeSMS   = "SMS"
+
+	// These urls are relative to AuthBaseUrl, everything else is relative to BaseUrl.
+	AuthUri                = "/auth"
+	FinishAuthUri          = "/finishAuth"
+	GetFactorsUri          = "/getFactors?data=%d"
+	StartAuthUri           = "/startAuth"
+	ValidateAccessTokenUri = "/validateAccessToken?data=%d"
 
 	// TODO: Implement all of the following urls. There are many here I don't have devices for. :/
 	ActiveAutomationUri           = "/users/devices/automation/active"
@@ -40,7 +55,7 @@ const (
 	CameraOrderUri                = "/users/devices/v2/displayOrder"
 	CancelPlanUri                 = "/users/payment/plans/%paymentId/cancel"
 	CancelQuotationUri            = "/users/payment/quotations/%paymentId/cancel/v1"
-	CapabilitiesUri               = "/resources/capabilities/en/en_%t|i1000.json?t=%s"
+	CapabilitiesUri               = "/resources/capabilities/en/en_%s|i1000.json?t=%d"
 	ChangeMobileOffersUri         = "/users/payment/offers/dataplans/change/v5"
 	ChangePlanUri                 = "/users/%paymentId/changeplan"
 	CheckAccountUri               = "/checkAccountUsage"
@@ -16,6 +16,13 @@
 
 package arlo
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
 // A Device is the device data, this can be a camera, basestation, arloq, etc.
 type Device struct {
 	arlo                          *Arlo        // Let's hold a reference to the parent arlo object since it holds the http.Client object and references to all devices.
@@ -77,19 +84,87 @@ type DeviceOrder struct {
 
 // Find returns a device with the device id passed in.
 func (ds *Devices) Find(deviceId string) *Device {
-	for _, d := range *ds {
-		if d.DeviceId == deviceId {
-			return &d
+	for i := range *ds {
+		if (*ds)[i].DeviceId == deviceId {
+			return &(*ds)[i]
 		}
 	}
 
 	return nil
 }
 
+// Snapshot returns a copy of the device. A device from the collections on the Arlo object is updated in place when the
+// devices are refreshed, so read its fields from a snapshot if they may be refreshed meanwhile.
+func (d *Device) Snapshot() Device {
+	if d.arlo == nil {
+		return *d
+	}
+	return d.arlo.devices.snapshot(d)
+}
+
+// DeviceCollection is all of the devices of the account, of every kind, which GetDevices keeps up to date. The other
+// collections on the Arlo object, like Cameras and Basestations, are views of the same devices, of their kinds.
+//
+// The collections are safe to use while the devices are being refreshed. A device keeps its pointer across refreshes,
+// and a refresh updates its fields in place, under the lock of the collections. So to read the fields of a device while
+// it may be refreshed, use Snapshot, which copies them under the lock. A device is only replaced by a new one if its id,
+// kind, parent, user id, xCloudId, unique id or model change, since its methods rely on those without the lock.
+//
+// The device of a camera, basestation, etc. is the one that's wrapped by it in the collection of its kind.
+type DeviceCollection struct {
+	view
+}
+
+// All kinds.
+var deviceKinds []DeviceKind
+
+// All returns the devices, in the order the Arlo API returned them.
+func (ds DeviceCollection) All() []*Device {
+	return devices(ds.list(nil))
+}
+
+// Range calls fn for each device, in order, until it returns false. It ranges over the devices there were when it was
+// called, so fn can refresh the devices.
+func (ds DeviceCollection) Range(fn func(d *Device) bool) {
+	for _, d := range ds.All() {
+		if !fn(d) {
+			return
+		}
+	}
+}
+
+// Find returns the device with the device id, or nil if there isn't one.
+func (ds DeviceCollection) Find(deviceId string) *Device {
+	return deviceOf(ds.findId(deviceId))
+}
+
+// FindByName returns the first device with the name, or nil if there isn't one.
+func (ds DeviceCollection) FindByName(name string) *Device {
+	return deviceOf(ds.find(byName(name)))
+}
+
+// FindByUniqueId returns the device with the unique id, or nil if there isn't one.
+func (ds DeviceCollection) FindByUniqueId(uniqueId string) *Device {
+	return deviceOf(ds.find(byUniqueId(uniqueId)))
+}
+
+// FindByModel returns the devices of the model.
+func (ds DeviceCollection) FindByModel(modelId string) []*Device {
+	return devices(ds.list(byModel(modelId)))
+}
+
+func devices(vs []interface{}) []*Device {
+	var devices []*Device
+	for _, v := range vs {
+		devices = append(devices, deviceOf(v))
+	}
+	return devices
+}
+
 func (ds Devices) FindCameras(basestationId string) Cameras {
 	cs := new(Cameras)
 	for _, d := range ds {
-		if d.ParentId == basestationId {
+		if d.ParentId == basestationId && d.Kind() == KindCamera {
 			*cs = append(*cs, Camera(d))
 		}
 	}
@@ -97,63 +172,167 @@ func (ds Devices) FindCameras(basestationId string) Cameras {
 	return *cs
 }
 
+// IsBasestation reports whether the device is a basestation. Arlo Qs and bridges connect to the event stream like
+// basestations do, but they're their own kinds.
 func (d Device) IsBasestation() bool {
-	return d.DeviceType == DeviceTypeBasestation || d.DeviceId == d.ParentId
+	return d.Kind() == KindBasestation
 }
 
+// IsCamera reports whether the device is a camera, including an Arlo Q.
 func (d Device) IsCamera() bool {
-	switch(d.DeviceType) {
-        case
-            DeviceTypeCamera,
-            DeviceTypeArloQ:
-        return true
-    }
-    return false
+	switch d.Kind() {
+	case KindCamera, KindArloQ:
+		return true
+	}
+	return false
 }
 
 func (d Device) IsArloQ() bool {
-	return d.DeviceType == DeviceTypeArloBridge
+	return d.Kind() == KindArloQ
+}
+
+func (d Device) IsBridge() bool {
+	return d.Kind() == KindBridge
+}
+
+func (d Device) IsDoorbell() bool {
+	return d.Kind() == KindDoorbell
+}
+
+func (d Device) IsChime() bool {
+	return d.Kind() == KindChime
 }
 
 func (d Device) IsLight() bool {
-	return d.DeviceType == DeviceTypeLights
+	return d.Kind() == KindLight
 }
 
 func (d Device) IsSiren() bool {
-	return d.DeviceType == DeviceTypeSiren
+	return d.Kind() == KindSiren
 }
 
-// GetBasestations returns a Basestations object containing all devices that are NOT type "camera".
-// I did this because some device types, like arloq, don't have a basestation.
-// So, when interacting with them you must treat them like a basestation and a camera.
-// Cameras also includes devices of this type, so you can get the same data there or cast.
+// GetBasestations returns a Basestations object containing all devices that are basestations.
+// Arlo Qs and bridges aren't included; they're returned by GetArloQs and GetBridges.
 func (ds Devices) GetBasestations() *Basestations {
 	basestations := new(Basestations)
 	for _, d := range ds {
-		if d.IsBasestation() || !d.IsCamera() {
+		if d.IsBasestation() {
 			*basestations = append(*basestations, Basestation{Device: d})
 		}
 	}
 	return basestations
 }
 
-// GetCameras returns a Cameras object containing all devices that are of type "camera".
-// I did this because some device types, like arloq, don't have a basestation.
-// So, when interacting with them you must treat them like a basestation and a camera.
-// Basestations also includes devices of this type, so you can get the same data there or cast.
+// GetArloQs returns an ArloQs object containing all devices that are Arlo Qs.
+func (ds Devices) GetArloQs() *ArloQs {
+	arloQs := new(ArloQs)
+	for _, d := range ds {
+		if d.IsArloQ() {
+			*arloQs = append(*arloQs, *newArloQ(d))
+		}
+	}
+	return arloQs
+}
+
+// GetBridges returns a Bridges object containing all devices that are Arlo Bridges.
+func (ds Devices) GetBridges() *Bridges {
+	bridges := new(Bridges)
+	for _, d := range ds {
+		if d.IsBridge() {
+			*bridges = append(*bridges, Bridge{Basestation{Device: d}})
+		}
+	}
+	return bridges
+}
+
+// GetDoorbells returns a Doorbells object containing all devices that are doorbells.
+func (ds Devices) GetDoorbells() *Doorbells {
+	doorbells := new(Doorbells)
+	for _, d := range ds {
+		if d.IsDoorbell() {
+			*doorbells = append(*doorbells, Doorbell(d))
+		}
+	}
+	return doorbells
+}
+
+// GetChimes returns a Chimes object containing all devices that are chimes.
+func (ds Devices) GetChimes() *Chimes {
+	chimes := new(Chimes)
+	for _, d := range ds {
+		if d.IsChime() {
+			*chimes = append(*chimes, Chime(d))
+		}
+	}
+	return chimes
+}
+
+// GetLights returns a Lights object containing all devices that are of type "lights".
+func (ds Devices) GetLights() *Lights {
+	lights := new(Lights)
+	for _, d := range ds {
+		if d.IsLight() {
+			*lights = append(*lights, Light(d))
+		}
+	}
+	return lights
+}
+
+// GetSirens returns a Sirens object containing all devices that are of type "siren".
+func (ds Devices) GetSirens() *Sirens {
+	sirens := new(Sirens)
+	for _, d := range ds {
+		if d.IsSiren() {
+			*sirens = append(*sirens, Siren(d))
+		}
+	}
+	return sirens
+}
+
+// GetCameras returns a Cameras object containing all devices that are cameras.
+// Arlo Qs aren't included; they're returned by GetArloQs, and each ArloQ has the camera operations too.
 func (ds Devices) GetCameras() *Cameras {
 	cameras := new(Cameras)
 	for _, d := range ds {
-		if d.IsCamera() || !d.IsBasestation() {
+		if d.Kind() == KindCamera {
 			*cameras = append(*cameras, Camera(d))
 		}
 	}
 	return cameras
 }
 
+// request sends the payload to the event stream of the basestation with the id, which the device is connected to.
+// It's shared by the devices that don't have an event stream of their own, like cameras, lights and sirens.
+func (d *Device) request(ctx context.Context, basestationId string, payload EventStreamPayload, msg string) (*EventStreamResponse, error) {
+	b := d.arlo.basestation(basestationId)
+	if b == nil {
+		err := fmt.Errorf("basestation (%s) not found for %s (%s)", basestationId, d.DeviceType, d.DeviceId)
+		return nil, errors.WithMessage(err, msg)
+	}
+	return b.makeEventStreamRequest(ctx, payload, msg)
+}
+
+// getState gets the properties of the device's resource, from the basestation with the id.
+func (d *Device) getState(ctx context.Context, basestationId string, resource string, msg string) (*EventStreamResponse, error) {
+	payload := EventStreamPayload{
+		Action:          "get",
+		Resource:        resource,
+		PublishResponse: false,
+		From:            fmt.Sprintf("%s_%s", d.UserId, TransIdPrefix),
+		To:              basestationId,
+	}
+
+	return d.request(ctx, basestationId, payload, msg)
+}
+
 // UpdateDeviceName sets the name of the given device to the name argument.
 func (d *Device) UpdateDeviceName(name string) error {
+	return d.UpdateDeviceNameContext(context.Background(), name)
+}
+
+// UpdateDeviceNameContext is like UpdateDeviceName, but uses ctx for the request.
+func (d *Device) UpdateDeviceNameContext(ctx context.Context, name string) error {
 	body := map[string]string{"deviceId": d.DeviceId, "deviceName": name, "parentId": d.ParentId}
-	resp, err := d.arlo.put(RenameDeviceUri, d.XCloudId, body, nil)
+	resp, err := d.arlo.put(ctx, RenameDeviceUri, d.XCloudId, body, nil)
 	return checkRequest(resp, err, "failed to update device name")
 }
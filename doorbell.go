@@ -0,0 +1,136 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// A Doorbell is a Device of type "doorbell". The audio doorbell only has a button; the video doorbell is a camera too,
+// which is returned by Camera().
+type Doorbell Device
+
+// Doorbells is a slice of Doorbell objects.
+type Doorbells []Doorbell
+
+// Find returns a doorbell with the device id passed in.
+func (dbs *Doorbells) Find(deviceId string) *Doorbell {
+	for i := range *dbs {
+		if (*dbs)[i].DeviceId == deviceId {
+			return &(*dbs)[i]
+		}
+	}
+
+	return nil
+}
+
+// DoorbellCollection is the doorbells of the account. It's a view of the devices, like DeviceCollection.
+type DoorbellCollection struct {
+	view
+}
+
+var doorbellKinds = []DeviceKind{KindDoorbell}
+
+// All returns the doorbells, in the order the Arlo API returned them.
+func (dbs DoorbellCollection) All() []*Doorbell {
+	return doorbells(dbs.list(nil))
+}
+
+// Range calls fn for each doorbell, in order, until it returns false. See DeviceCollection.Range.
+func (dbs DoorbellCollection) Range(fn func(db *Doorbell) bool) {
+	for _, db := range dbs.All() {
+		if !fn(db) {
+			return
+		}
+	}
+}
+
+// Find returns the doorbell with the device id, or nil if there isn't one.
+func (dbs DoorbellCollection) Find(deviceId string) *Doorbell {
+	db, _ := dbs.findId(deviceId).(*Doorbell)
+	return db
+}
+
+// FindByName returns the first doorbell with the name, or nil if there isn't one.
+func (dbs DoorbellCollection) FindByName(name string) *Doorbell {
+	db, _ := dbs.find(byName(name)).(*Doorbell)
+	return db
+}
+
+// FindByUniqueId returns the doorbell with the unique id, or nil if there isn't one.
+func (dbs DoorbellCollection) FindByUniqueId(uniqueId string) *Doorbell {
+	db, _ := dbs.find(byUniqueId(uniqueId)).(*Doorbell)
+	return db
+}
+
+// FindByModel returns the doorbells of the model.
+func (dbs DoorbellCollection) FindByModel(modelId string) []*Doorbell {
+	return doorbells(dbs.list(byModel(modelId)))
+}
+
+func doorbells(vs []interface{}) []*Doorbell {
+	var doorbells []*Doorbell
+	for _, db := range vs {
+		doorbells = append(doorbells, db.(*Doorbell))
+	}
+	return doorbells
+}
+
+func (db *Doorbell) resource() string {
+	return fmt.Sprintf("doorbells/%s", db.DeviceId)
+}
+
+// HasVideo reports whether the doorbell is a video doorbell.
+func (db *Doorbell) HasVideo() bool {
+	return strings.HasPrefix(db.ModelId, "AVD")
+}
+
+// Camera returns the camera of a video doorbell, or nil if the doorbell doesn't have one.
+func (db *Doorbell) Camera() *Camera {
+	if !db.HasVideo() {
+		return nil
+	}
+	c := Camera(*db)
+	return &c
+}
+
+// GetState returns the current state of the doorbell.
+func (db *Doorbell) GetState() (response *EventStreamResponse, err error) {
+	return db.GetStateContext(context.Background())
+}
+
+// GetStateContext is like GetState, but uses ctx for the request.
+func (db *Doorbell) GetStateContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return (*Device)(db).getState(ctx, db.ParentId, db.resource(), "failed to get doorbell state")
+}
+
+// Events returns a listener for the events of the doorbell. Call Unsubscribe() on the listener when you're done.
+func (db *Doorbell) Events() *EventListener {
+	return db.arlo.listeners.add(EventFilter{Resource: db.resource()}, db.ParentId)
+}
+
+// OnPress calls handler every time the button of the doorbell is pressed.
+// The handler is called from its own goroutine, one event at a time. Call Unsubscribe() on the returned listener to stop it.
+func (db *Doorbell) OnPress(handler func()) *EventListener {
+	return db.arlo.listeners.addFunc(EventFilter{Resource: db.resource(), Action: "is"}, db.ParentId, func(e *EventStreamResponse) {
+		if properties, ok := e.Properties.(map[string]interface{}); ok && properties["buttonPressed"] == true {
+			handler()
+		}
+	})
+}
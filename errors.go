@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"strconv"
+
+	"github.com/jeffreydwalter/arlo-go/internal/request"
+)
+
+// APIError is returned when the Arlo API rejects a request. Use errors.As to get at it:
+//
+//	var apiErr *arlo.APIError
+//	if errors.As(err, &apiErr) {
+//		log.Println(apiErr.StatusCode, apiErr.Reason)
+//	}
+type APIError = request.APIError
+
+// IsUnauthorized returns true if err is because the auth token was rejected.
+func IsUnauthorized(err error) bool {
+	return request.IsUnauthorized(err)
+}
+
+// IsRateLimited returns true if err is because too many requests were made.
+func IsRateLimited(err error) bool {
+	return request.IsRateLimited(err)
+}
+
+// IsDeviceOffline returns true if err is because the device the request was for is offline.
+func IsDeviceOffline(err error) bool {
+	return request.IsDeviceOffline(err)
+}
+
+// IsTimeout returns true if err is because a request, or the wait for its response from the event stream, timed out.
+func IsTimeout(err error) bool {
+	return request.IsTimeout(err)
+}
+
+// timeoutError is returned when the event stream doesn't answer in time.
+type timeoutError struct {
+	error
+}
+
+func (timeoutError) Timeout() bool {
+	return true
+}
+
+// statusError returns the error for a response whose Status says it didn't succeed.
+func statusError(resp *request.Response, status Status) error {
+	return resp.APIError(status.Error, status.Reason, status.Message)
+}
+
+// metaError returns the error for a response from the authentication api that didn't succeed.
+func metaError(resp *request.Response, meta AuthMeta) error {
+	var code string
+	if meta.Meta.Error != 0 {
+		code = strconv.Itoa(meta.Meta.Error)
+	}
+	return resp.APIError(code, "", meta.Meta.Message)
+}
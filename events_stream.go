@@ -18,15 +18,44 @@ package arlo
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/jeffreydwalter/arlo-go/internal/util"
 
 	"github.com/pkg/errors"
 
 	"github.com/r3labs/sse"
 )
 
+// eventBufferSize is the number of events buffered for each EventListener.
+const eventBufferSize = 32
+
+const (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 2 * time.Minute
+
+	// reconnectTimeout is how long a request waits for a dropped event stream to come back.
+	reconnectTimeout = 1 * time.Minute
+
+	// eventStreamRetries is how many times a request is resent when the event stream drops while it's waiting for a response.
+	eventStreamRetries = 3
+)
+
+// When the connection to the event stream changes, listeners get a message with the EventStreamResource resource and
+// a Status of EventStreamConnected or EventStreamDisconnected.
+const (
+	EventStreamResource     = "eventStream"
+	EventStreamConnected    = "connected"
+	EventStreamDisconnected = "disconnected"
+)
+
 var (
 	FAILED_TO_PUBLISH     = errors.New("failed to publish")
 	FAILED_TO_DECODE_JSON = errors.New("failed to decode json")
@@ -42,94 +71,528 @@ type subscriptions struct {
 	rwmutex sync.RWMutex
 }
 
+// A connection is a single connection to the event stream. A new one is made every time the event stream reconnects.
+type connection struct {
+	up     chan struct{} // closed when arlo confirms the connection.
+	ready  chan struct{} // closed when onConnect has succeeded and requests can be made.
+	lost   chan struct{} // closed when the connection is dropped.
+	cancel context.CancelFunc
+}
+
+func newConnection() *connection {
+	return &connection{
+		up:    make(chan struct{}),
+		ready: make(chan struct{}),
+		lost:  make(chan struct{}),
+	}
+}
+
+// An eventStream is a supervised connection to the arlo event stream (server-sent events).
+// If the connection drops, it reconnects with exponential backoff until disconnect() is called.
 type eventStream struct {
-	SSEClient    *sse.Client
-	Events       chan *sse.Event
-	Error        chan error
-	Verbose      bool
-	Disconnected chan interface{}
+	url          string
+	client       *http.Client
+	timeout      time.Duration // How long to wait for a response, when the context doesn't have a deadline.
+	Disconnected chan struct{}
 	once         *sync.Once
+	userAgent    string
+	dispatch     func(*EventStreamResponse)
+	onConnect    func(*connection) error
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	conn      *connection
+	connMutex sync.RWMutex
 
 	subscriptions
 }
 
-func newEventStream(url string, client *http.Client) *eventStream {
-
-	SSEClient := sse.NewClient(url)
-	SSEClient.Connection = client
+func newEventStream(url string, client *http.Client, timeout time.Duration) *eventStream {
+	ctx, cancel := context.WithCancel(context.Background())
 
 	return &eventStream{
-		SSEClient:     SSEClient,
-		Events:        make(chan *sse.Event),
+		url:           url,
+		client:        client,
+		timeout:       timeout,
 		subscriptions: subscriptions{make(map[string]subscriber), sync.RWMutex{}},
-		Error:         make(chan error),
-		Disconnected:  make(chan interface{}),
+		Disconnected:  make(chan struct{}),
 		once:          new(sync.Once),
+		ctx:           ctx,
+		cancel:        cancel,
+		conn:          newConnection(),
 	}
 }
 
+// disconnect closes the event stream for good.
 func (e *eventStream) disconnect() {
 	e.once.Do(func() {
 		close(e.Disconnected)
+		e.cancel()
 	})
 }
 
-func (e *eventStream) listen() (connected chan bool) {
-	connected = make(chan bool)
+// reconnect drops the current connection, which makes the event stream reconnect.
+func (e *eventStream) reconnect() {
+	e.connMutex.RLock()
+	defer e.connMutex.RUnlock()
+	if e.conn.cancel != nil {
+		e.conn.cancel()
+	}
+}
 
-	go func() {
-		e.SSEClient.OnDisconnect(func(c *sse.Client) {
+// setURL changes the url used for the next connection.
+func (e *eventStream) setURL(url string) {
+	e.connMutex.Lock()
+	defer e.connMutex.Unlock()
+	e.url = url
+}
+
+func (e *eventStream) current() *connection {
+	e.connMutex.RLock()
+	defer e.connMutex.RUnlock()
+	return e.conn
+}
+
+// drop marks the connection as lost and sets up the next one.
+func (e *eventStream) drop(conn *connection) {
+	e.connMutex.Lock()
+	close(conn.lost)
+	e.conn = newConnection()
+	e.connMutex.Unlock()
+
+	if isClosed(conn.ready) {
+		e.emit(EventStreamDisconnected)
+	}
+}
+
+// emit tells the listeners about a change to the connection.
+func (e *eventStream) emit(status string) {
+	if e.dispatch != nil {
+		e.dispatch(&EventStreamResponse{EventStreamPayload: EventStreamPayload{Resource: EventStreamResource}, Status: status})
+	}
+}
+
+// start connects to the event stream, and keeps it connected in the background.
+// It blocks until the first connection is ready, and returns an error if it fails.
+// If ctx is done before the event stream is ready, the event stream is closed.
+func (e *eventStream) start(ctx context.Context) error {
+	conn := e.current()
+	failed := make(chan error, 1)
+
+	go e.run(failed)
+
+	select {
+	case <-conn.ready:
+		return nil
+	case err := <-failed:
+		return err
+	case <-ctx.Done():
+		e.disconnect()
+		return ctx.Err()
+	}
+}
+
+func (e *eventStream) run(failed chan<- error) {
+	defer e.disconnect()
+
+	for attempt := 0; ; attempt++ {
+		conn := e.current()
+		err := e.connect(conn)
+		if isClosed(conn.ready) {
+			attempt = 0
+		} else if failed != nil {
+			// Don't keep trying if we couldn't connect in the first place.
+			failed <- err
+			return
+		}
+		failed = nil
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-time.After(util.Backoff(attempt, minReconnectDelay, maxReconnectDelay)):
+		}
+	}
+}
+
+// connect makes a single connection to the event stream and reads from it until it's dropped.
+func (e *eventStream) connect(conn *connection) error {
+	ctx, cancel := context.WithCancel(e.ctx)
+
+	e.connMutex.Lock()
+	conn.cancel = cancel
+	url := e.url
+	e.connMutex.Unlock()
+
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		e.drop(conn)
+		wg.Wait()
+	}()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create event stream request")
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if e.userAgent != "" {
+		req.Header.Set("User-Agent", e.userAgent)
+	}
+
+	resp, err := e.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to event stream")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		err := &APIError{StatusCode: resp.StatusCode, Endpoint: req.Method + " " + req.URL.Path}
+		return errors.WithMessage(err, "failed to connect to event stream")
+	}
+
+	reader := sse.NewEventStreamReader(resp.Body)
+	for {
+		event, err := reader.ReadEvent()
+		if err == io.EOF {
+			return errors.New("event stream was closed")
+		} else if err != nil {
+			return errors.Wrap(err, "failed to read from event stream")
+		}
+
+		data := eventData(event)
+		if len(data) == 0 {
+			continue
+		}
+
+		notifyResponse := &EventStreamResponse{}
+		if err := json.Unmarshal(data, notifyResponse); err != nil {
+			continue
+		}
+		var raw struct {
+			Properties json.RawMessage `json:"properties"`
+		}
+		if json.Unmarshal(data, &raw) == nil {
+			notifyResponse.RawProperties = raw.Properties
+		}
+
+		if notifyResponse.Status == "connected" {
+			if isClosed(conn.up) {
+				continue
+			}
+			close(conn.up)
+
+			// onConnect makes requests on the event stream, so it can't block the read loop.
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if e.onConnect != nil {
+					if err := e.onConnect(conn); err != nil {
+						cancel()
+						return
+					}
+				}
+				close(conn.ready)
+				e.emit(EventStreamConnected)
+			}()
+		} else if notifyResponse.Status == "disconnected" {
+			return errors.New("event stream was disconnected by the server")
+		} else if notifyResponse.Action == "logout" {
 			e.disconnect()
-			// fmt.Printf("\n\n\n\nClIENT DISCONNECTED!!!!!\n\n\n\n")
-		})
-		err := e.SSEClient.SubscribeChanRaw(e.Events)
+			return errors.New("event stream was logged out")
+		} else {
+			// Hand every message to the listeners, including the ones that weren't requested by us.
+			// It's done first, so the state of the devices is up to date by the time a request gets its response.
+			if e.dispatch != nil {
+				e.dispatch(notifyResponse)
+			}
+
+			e.subscriptions.rwmutex.RLock()
+			if subscriber, ok := e.subscribers[notifyResponse.TransId]; ok {
+				select {
+				case subscriber <- notifyResponse:
+				default:
+				}
+			}
+			e.subscriptions.rwmutex.RUnlock()
+		}
+	}
+}
+
+// waitReady blocks until the event stream is ready for requests, or ctx is done.
+func (e *eventStream) waitReady(ctx context.Context) (*connection, error) {
+	timer := time.NewTimer(reconnectTimeout)
+	defer timer.Stop()
+
+	for {
+		conn := e.current()
+		select {
+		case <-conn.ready:
+			return conn, nil
+		case <-conn.lost:
+			// This connection was dropped before it was ready, wait for the next one.
+		case <-e.Disconnected:
+			return nil, errors.New("basestation not connected to event stream")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+			return nil, fmt.Errorf("event stream did not reconnect after %.0f seconds", reconnectTimeout.Seconds())
+		}
+	}
+}
+
+// request sends the payload, using send, and blocks waiting for the response with the same transId.
+// If the event stream drops while waiting, it waits for it to reconnect and sends the payload again.
+// If conn is not nil, the request is only made on that connection (this is used by onConnect).
+// If ctx has a deadline, it's used instead of the event stream timeout to wait for the response.
+func (e *eventStream) request(ctx context.Context, payload EventStreamPayload, send func(context.Context, EventStreamPayload) error, conn *connection, msg string) (*EventStreamResponse, error) {
+	subscriber := make(subscriber, 1)
+
+	// Add the response channel to the event stream queue so the response can be written to it.
+	e.subscribe(payload.TransId, subscriber)
+	// Make sure we close and remove the response channel before returning.
+	defer e.unsubscribe(payload.TransId)
+
+	for attempt := 0; ; attempt++ {
+		c := conn
+		if c == nil {
+			var err error
+			if c, err = e.waitReady(ctx); err != nil {
+				return nil, errors.WithMessage(err, msg)
+			}
+		}
+
+		// Send the payload to the event stream.
+		if err := send(ctx, payload); err != nil {
+			return nil, errors.WithMessage(err, msg)
+		}
+
+		// Without a deadline on ctx, fall back to the default timeout.
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if _, ok := ctx.Deadline(); !ok {
+			timer = time.NewTimer(e.timeout)
+			timeout = timer.C
+		}
+
+		var response *EventStreamResponse
+		var err error
+		retry := false
+
+		// Wait for the response to come back from the event stream on the response channel.
+		select {
+		// If we get a response, return it to the caller.
+		case response = <-subscriber:
+		// If the connection drops, send the request again once it's back.
+		case <-c.lost:
+			if conn != nil || attempt >= eventStreamRetries {
+				err = errors.New("event stream connection was lost before response was read")
+			} else {
+				retry = true
+			}
+		// If the event stream is closed, return an error about it.
+		case <-e.Disconnected:
+			err = errors.New("event stream was closed before response was read")
+		// If the caller gives up, return the reason.
+		case <-ctx.Done():
+			err = ctx.Err()
+		// If we timeout, return an error about it.
+		case <-timeout:
+			err = timeoutError{fmt.Errorf("event stream response timed out after %s", e.timeout)}
+		}
+
+		// Each attempt has its own timer, so stop it now rather than when the request returns.
+		if timer != nil {
+			timer.Stop()
+		}
+
 		if err != nil {
-			e.Error <- FAILED_TO_SUBSCRIBE
-		}
-
-		for {
-			select {
-			case event := <-e.Events:
-				//fmt.Println("Got event message.")
-				/*
-					fmt.Print(".")
-					fmt.Printf("EVENT: %s\n", event.Event)
-					fmt.Printf("DATA: %s\n", event.Data)
-				*/
-
-				if event != nil && event.Data != nil {
-					notifyResponse := &EventStreamResponse{}
-					b := bytes.NewBuffer(event.Data)
-					err := json.NewDecoder(b).Decode(notifyResponse)
-					if err != nil {
-						e.Error <- FAILED_TO_DECODE_JSON
-						break
-					}
+			return nil, errors.WithMessage(err, msg)
+		}
+		if !retry {
+			return response, nil
+		}
+	}
+}
 
-					// FIXME: This is a shitty way to handle this. It's potentially leaking a chan.
-					if notifyResponse.Status == "connected" {
-						connected <- true
-					} else if notifyResponse.Status == "disconnected" {
-						e.disconnect()
-					} else if notifyResponse.Action == "logout" {
-						e.disconnect()
-					} else {
-						e.subscriptions.rwmutex.RLock()
-						subscriber, ok := e.subscribers[notifyResponse.TransId]
-						e.subscriptions.rwmutex.RUnlock()
-						if ok {
-							subscriber <- notifyResponse
-						}
-					}
+// subscribe adds the basestation to the event stream, connecting to the event stream first if necessary.
+// ctx bounds connecting to the event stream and subscribing the basestation, but not the life of the event stream.
+func (a *Arlo) subscribe(ctx context.Context, b *Basestation) error {
+	a.eventStreamMutex.Lock()
+	defer a.eventStreamMutex.Unlock()
+
+	a.rwmutex.Lock()
+	a.subscribed[b.DeviceId] = true
+	es := a.eventStream
+	a.rwmutex.Unlock()
+
+	if es != nil && !isClosed(es.Disconnected) {
+		// The event stream is already connected, so only this basestation needs to be subscribed to it.
+		if err := b.PingContext(ctx); err != nil {
+			a.rwmutex.Lock()
+			delete(a.subscribed, b.DeviceId)
+			a.rwmutex.Unlock()
+			return err
+		}
+		return nil
+	}
+
+	// The event stream is long lived, so it doesn't get the timeout of the other requests.
+	client := *a.client.HttpClient
+	client.Timeout = 0
+	es = newEventStream(a.streamURL(), &client, a.eventStreamTimeout)
+	es.userAgent = a.userAgent
+	es.dispatch = a.dispatch
+	// Every new connection to the event stream has to be subscribed to the events of each basestation.
+	es.onConnect = func(conn *connection) error {
+		return a.resubscribe(es, conn)
+	}
+
+	a.rwmutex.Lock()
+	a.eventStream = es
+	a.rwmutex.Unlock()
+
+	if err := es.start(ctx); err != nil {
+		a.rwmutex.Lock()
+		delete(a.subscribed, b.DeviceId)
+		a.rwmutex.Unlock()
+		return err
+	}
+
+	go a.keepAlive(es)
+
+	return nil
+}
+
+// unsubscribe removes the basestation from the event stream. The event stream is closed when no basestations are left.
+func (a *Arlo) unsubscribe(deviceId string) {
+	a.eventStreamMutex.Lock()
+	defer a.eventStreamMutex.Unlock()
+
+	a.rwmutex.Lock()
+	delete(a.subscribed, deviceId)
+	es := a.eventStream
+	last := len(a.subscribed) == 0
+	if last {
+		a.eventStream = nil
+	}
+	a.rwmutex.Unlock()
+
+	if es != nil && last {
+		es.disconnect()
+	}
+}
+
+// disconnect closes the event stream and unsubscribes all of the basestations.
+func (a *Arlo) disconnect() {
+	a.eventStreamMutex.Lock()
+	defer a.eventStreamMutex.Unlock()
+
+	a.rwmutex.Lock()
+	a.subscribed = make(map[string]bool)
+	es := a.eventStream
+	a.eventStream = nil
+	a.rwmutex.Unlock()
+
+	if es != nil {
+		es.disconnect()
+	}
+}
+
+// streamURL returns the event stream url, which includes the auth token.
+func (a *Arlo) streamURL() string {
+	return a.client.BaseURL.String() + fmt.Sprintf(NotifyResponsesPushServiceUri, a.CurrentAccount().Token)
+}
+
+func (a *Arlo) stream() *eventStream {
+	a.rwmutex.RLock()
+	defer a.rwmutex.RUnlock()
+	return a.eventStream
+}
+
+func (a *Arlo) isSubscribed(deviceId string) bool {
+	a.rwmutex.RLock()
+	defer a.rwmutex.RUnlock()
+	_, ok := a.subscribed[deviceId]
+	return ok
+}
+
+// subscribedBasestations returns the subscribed basestations, as they are in the devices now, so a refresh that changed
+// them is seen by the pings and the resubscriptions.
+func (a *Arlo) subscribedBasestations() []*Basestation {
+	a.rwmutex.RLock()
+	ids := make([]string, 0, len(a.subscribed))
+	for id := range a.subscribed {
+		ids = append(ids, id)
+	}
+	a.rwmutex.RUnlock()
+
+	basestations := make([]*Basestation, 0, len(ids))
+	for _, id := range ids {
+		if b := a.basestation(id); b != nil {
+			basestations = append(basestations, b)
+		}
+	}
+	return basestations
+}
+
+// resubscribe subscribes each basestation on a new connection to the event stream.
+func (a *Arlo) resubscribe(es *eventStream, conn *connection) error {
+	for _, b := range a.subscribedBasestations() {
+		msg := "failed to ping the event stream"
+		if _, err := es.request(es.ctx, b.subscriptionPayload(), b.notify(msg), conn, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepAlive pings each basestation every ping interval (30s by default), which the Arlo event stream requires, until the event stream is closed.
+// If a ping fails, the event stream is reconnected.
+func (a *Arlo) keepAlive(es *eventStream) {
+	ticker := time.NewTicker(a.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, b := range a.subscribedBasestations() {
+				if err := b.PingContext(es.ctx); err != nil && a.isSubscribed(b.DeviceId) {
+					es.reconnect()
+					break
 				}
-			case <-e.Disconnected:
-				connected <- false
-				return
 			}
+		case <-es.Disconnected:
+			return
 		}
-	}()
+	}
+}
+
+// dispatch updates the state of the devices with a message from the event stream, and hands it to the listeners of the
+// basestation it came from.
+func (a *Arlo) dispatch(event *EventStreamResponse) {
+	basestationId := a.basestationOf(event)
+	a.states.apply(basestationId, event)
+	a.streamEnded(event)
+	a.listeners.dispatch(basestationId, event)
+}
 
-	return connected
+// basestationOf returns the device id of the basestation that sent the event, or "" if it's unknown.
+func (a *Arlo) basestationOf(event *EventStreamResponse) string {
+	a.rwmutex.RLock()
+	defer a.rwmutex.RUnlock()
+
+	if _, ok := a.subscribed[event.From]; ok {
+		return event.From
+	}
+	if _, ok := a.subscribed[event.DeviceId]; ok {
+		return event.DeviceId
+	}
+	if c := a.Cameras.Find(event.DeviceId); c != nil {
+		return c.ParentId
+	}
+	return ""
 }
 
 func (s *subscriptions) unsubscribe(transId string) {
@@ -147,3 +610,132 @@ func (s *subscriptions) subscribe(transId string, subscriber subscriber) {
 	s.subscribers[transId] = subscriber
 	s.rwmutex.Unlock()
 }
+
+// An EventFilter selects which event stream messages are delivered to an EventListener. Empty fields match anything.
+type EventFilter struct {
+	Resource string
+	Action   string
+	DeviceId string
+	From     string
+}
+
+// Match reports whether the event matches every non-empty field of the filter.
+// DeviceId matches either the deviceId of the event or the last element of its resource (e.g. "cameras/<deviceId>").
+func (f EventFilter) Match(e *EventStreamResponse) bool {
+	if f.Resource != "" && f.Resource != e.Resource {
+		return false
+	}
+	if f.Action != "" && f.Action != e.Action {
+		return false
+	}
+	if f.From != "" && f.From != e.From {
+		return false
+	}
+	if f.DeviceId != "" && f.DeviceId != e.DeviceId && !strings.HasSuffix(e.Resource, "/"+f.DeviceId) {
+		return false
+	}
+	return true
+}
+
+// An EventListener receives every event stream message that matches its filter on the Events channel.
+// Events are buffered; if the buffer is full because the channel isn't being read, new events are dropped.
+type EventListener struct {
+	Events <-chan *EventStreamResponse
+
+	events        chan *EventStreamResponse
+	filter        EventFilter
+	basestationId string
+	listeners     *listeners
+}
+
+// Unsubscribe stops the delivery of events and closes the Events channel.
+func (l *EventListener) Unsubscribe() {
+	l.listeners.remove(l)
+}
+
+type listeners struct {
+	set     map[*EventListener]bool
+	rwmutex sync.RWMutex
+}
+
+func newListeners() *listeners {
+	return &listeners{set: make(map[*EventListener]bool)}
+}
+
+// add registers a new listener. If basestationId is not empty, the listener only receives events from that basestation.
+func (ls *listeners) add(filter EventFilter, basestationId string) *EventListener {
+	events := make(chan *EventStreamResponse, eventBufferSize)
+	l := &EventListener{
+		Events:        events,
+		events:        events,
+		filter:        filter,
+		basestationId: basestationId,
+		listeners:     ls,
+	}
+
+	ls.rwmutex.Lock()
+	ls.set[l] = true
+	ls.rwmutex.Unlock()
+
+	return l
+}
+
+// addFunc registers a new listener and calls handler, in its own goroutine, for each event it receives.
+func (ls *listeners) addFunc(filter EventFilter, basestationId string, handler func(*EventStreamResponse)) *EventListener {
+	l := ls.add(filter, basestationId)
+	go func() {
+		for event := range l.Events {
+			handler(event)
+		}
+	}()
+	return l
+}
+
+func (ls *listeners) remove(l *EventListener) {
+	ls.rwmutex.Lock()
+	defer ls.rwmutex.Unlock()
+	if _, ok := ls.set[l]; ok {
+		close(l.events)
+		delete(ls.set, l)
+	}
+}
+
+// dispatch delivers an event that came from the given basestation to all of the matching listeners.
+// Listeners of a basestation only get its own events, and the changes to the connection to the event stream.
+// It never blocks; slow listeners miss events instead of stalling the event stream.
+func (ls *listeners) dispatch(basestationId string, event *EventStreamResponse) {
+	ls.rwmutex.RLock()
+	defer ls.rwmutex.RUnlock()
+	for l := range ls.set {
+		if l.basestationId != "" && l.basestationId != basestationId && event.Resource != EventStreamResource {
+			continue
+		}
+		if !l.filter.Match(event) {
+			continue
+		}
+		select {
+		case l.events <- event:
+		default:
+		}
+	}
+}
+
+// eventData returns the data fields of a raw server-sent event. Multiple data fields are joined with a "\n".
+func eventData(event []byte) []byte {
+	var data [][]byte
+	for _, line := range bytes.FieldsFunc(event, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if bytes.HasPrefix(line, []byte("data:")) {
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" ")))
+		}
+	}
+	return bytes.Join(data, []byte("\n"))
+}
+
+func isClosed(c chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
@@ -3,7 +3,7 @@ module github.com/jeffreydwalter/arlo-go
 go 1.13
 
 require (
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
 	github.com/r3labs/sse v0.0.0-20191120111931-24eacf438413
 	golang.org/x/net v0.0.0-20191126235420-ef20fe5d7933
 )
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CameraHealth is the battery and connection of a camera, from its latest known state.
+type CameraHealth struct {
+	DeviceId        string
+	BatteryLevel    int    // Percent, or -1 if it's not known, e.g. for a camera without a battery.
+	CriticalBattery bool   // The Arlo API considers the battery critically low.
+	ChargingState   string // e.g. "On" or "Off".
+	ChargerTech     string // e.g. "None", "Regular" or "QuickCharger".
+	SignalStrength  int    // Bars, from 0 to 5, or -1 if it's not known.
+	ConnectionState string // e.g. "available" or "unavailable".
+	LastSeen        time.Time
+	Updated         time.Time // When the state last changed.
+}
+
+// Charging reports whether the battery is charging.
+func (h CameraHealth) Charging() bool {
+	return strings.EqualFold(h.ChargingState, "on")
+}
+
+// healthOf returns the health of the camera with the state.
+func healthOf(state DeviceState) CameraHealth {
+	// The device data that the state was seeded with doesn't fit a CameraState everywhere, so a failed decode still
+	// leaves the fields it got to.
+	var camera CameraState
+	state.Decode(&camera)
+
+	h := CameraHealth{
+		DeviceId:        state.DeviceId,
+		BatteryLevel:    -1,
+		ChargingState:   camera.ChargingState,
+		ChargerTech:     camera.ChargerTech,
+		SignalStrength:  -1,
+		ConnectionState: camera.ConnectionState,
+		Updated:         state.Updated,
+	}
+	if _, ok := state.Property("batteryLevel"); ok {
+		h.BatteryLevel = camera.BatteryLevel
+	}
+	if _, ok := state.Property("signalStrength"); ok {
+		h.SignalStrength = camera.SignalStrength
+	}
+	if critical, ok := state.Property("criticalBatteryState"); ok {
+		h.CriticalBattery, _ = critical.(bool)
+	}
+	if camera.LastSeen > 0 {
+		h.LastSeen = time.Unix(0, camera.LastSeen*int64(time.Millisecond))
+	}
+	return h
+}
+
+// CameraHealth returns the health of the camera with the device id, from its latest known state. The battery and signal
+// are only known once the camera has reported them on the event stream; RefreshHealth asks every camera for them.
+func (a *Arlo) CameraHealth(deviceId string) (health CameraHealth, ok bool) {
+	state, ok := a.states.get(deviceId)
+	if !ok {
+		return CameraHealth{}, false
+	}
+	return healthOf(state), true
+}
+
+// CamerasHealth returns the health of every camera, including the Arlo Qs, in the order the Arlo API returned them.
+func (a *Arlo) CamerasHealth() []CameraHealth {
+	var health []CameraHealth
+	a.Devices.Range(func(d *Device) bool {
+		if h, ok := a.CameraHealth(d.DeviceId); ok && d.IsCamera() {
+			health = append(health, h)
+		}
+		return true
+	})
+	return health
+}
+
+// Health returns the health of the camera, from its latest known state.
+func (c *Camera) Health() (health CameraHealth, ok bool) {
+	return c.arlo.CameraHealth(c.DeviceId)
+}
+
+// RefreshHealth gets the state of the cameras of every basestation, which updates their health.
+func (a *Arlo) RefreshHealth() error {
+	return a.RefreshHealthContext(context.Background())
+}
+
+// RefreshHealthContext is like RefreshHealth, but uses ctx for the requests.
+func (a *Arlo) RefreshHealthContext(ctx context.Context) error {
+	for _, b := range a.hubs() {
+		if _, err := b.GetAssociatedCamerasStateContext(ctx); err != nil {
+			return errors.WithMessage(err, "failed to refresh health")
+		}
+	}
+	return nil
+}
+
+// A HealthAlertType is the threshold of a HealthAlert.
+type HealthAlertType string
+
+const (
+	HealthLowBattery HealthAlertType = "lowBattery"
+	HealthLowSignal  HealthAlertType = "lowSignal"
+)
+
+// HealthThresholds are the levels below which a camera's battery and signal are low. A zero threshold is never crossed.
+type HealthThresholds struct {
+	Battery int // Percent.
+	Signal  int // Bars.
+}
+
+// A HealthAlert is a camera whose battery or signal dropped below its threshold, or, if Cleared, went back up to it.
+type HealthAlert struct {
+	Type    HealthAlertType
+	Cleared bool
+	Health  CameraHealth
+}
+
+// A HealthMonitor calls a handler with the health alerts of the cameras. Call Unsubscribe() to stop it.
+type HealthMonitor struct {
+	listener *StateListener
+}
+
+// Unsubscribe stops the monitor. The changes it already received are still handled.
+func (m *HealthMonitor) Unsubscribe() {
+	m.listener.Unsubscribe()
+}
+
+// OnHealthAlert calls handler when the battery or signal of a camera drops below its threshold, as it's reported on the
+// event stream, and again when it's back up. A camera that's already low when OnHealthAlert is called, or when its
+// level is first known, is alerted right away. Only cameras are checked. The handler is called from its own goroutine,
+// one alert at a time.
+func (a *Arlo) OnHealthAlert(thresholds HealthThresholds, handler func(HealthAlert)) *HealthMonitor {
+	m := &HealthMonitor{listener: a.states.add("")}
+
+	// The listener is added first, so no change is missed between the snapshot and the changes. A state that's in both
+	// is only alerted once, since the alerts are only sent when a camera crosses a threshold.
+	states := a.states.all()
+
+	go func() {
+		low := make(map[string]map[HealthAlertType]bool) // The alerts that are raised, by device id.
+		check := func(state DeviceState) {
+			if d := a.Devices.Find(state.DeviceId); d == nil || !d.IsCamera() {
+				return
+			}
+
+			h := healthOf(state)
+			if low[h.DeviceId] == nil {
+				low[h.DeviceId] = make(map[HealthAlertType]bool)
+			}
+
+			for _, check := range []struct {
+				alert     HealthAlertType
+				level     int
+				threshold int
+			}{
+				{HealthLowBattery, h.BatteryLevel, thresholds.Battery},
+				{HealthLowSignal, h.SignalStrength, thresholds.Signal},
+			} {
+				if check.level < 0 {
+					continue
+				}
+				isLow := check.level < check.threshold
+				if isLow != low[h.DeviceId][check.alert] {
+					low[h.DeviceId][check.alert] = isLow
+					handler(HealthAlert{Type: check.alert, Cleared: !isLow, Health: h})
+				}
+			}
+		}
+
+		for _, state := range states {
+			check(state)
+		}
+		for state := range m.listener.Changes {
+			check(state)
+		}
+	}()
+
+	return m
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// The interfaces below are implemented by *Arlo and the device types. Depend on them instead of the concrete types
+// to be able to swap in the mocks from the arlomock package in tests.
+//
+// They only have the Context variants of the methods, since those are the ones that can be canceled.
+
+// CameraController controls a camera.
+type CameraController interface {
+	OnContext(ctx context.Context) (*EventStreamResponse, error)
+	OffContext(ctx context.Context) (*EventStreamResponse, error)
+	SetBrightnessContext(ctx context.Context, brightness int) (*EventStreamResponse, error)
+	EnableMotionAlertsContext(ctx context.Context, sensitivity int, zones []string) (*EventStreamResponse, error)
+	DisableMotionAlertsContext(ctx context.Context, sensitivity int, zones []string) (*EventStreamResponse, error)
+	EnableAudioAlertsContext(ctx context.Context, sensitivity int) (*EventStreamResponse, error)
+	DisableAudioAlertsContext(ctx context.Context, sensitivity int) (*EventStreamResponse, error)
+	PushToTalkContext(ctx context.Context) error
+	SetAlertNotificationMethodsContext(ctx context.Context, action string, email, push bool) (*EventStreamResponse, error)
+	StartStreamContext(ctx context.Context) (string, error)
+	StopStreamContext(ctx context.Context) error
+	TakeSnapshotContext(ctx context.Context) (string, error)
+	TriggerFullFrameSnapshotContext(ctx context.Context) (string, error)
+	DownloadFullFrameSnapshotContext(ctx context.Context, w io.Writer) (string, error)
+	StartRecordingContext(ctx context.Context) (string, error)
+	StopRecordingContext(ctx context.Context) error
+	GetCvrPlaylistContext(ctx context.Context, fromDate, toDate time.Time) (*CvrPlaylist, error)
+}
+
+// ArloBabyController controls an Arlo Baby camera, which also has a speaker and a night light.
+type ArloBabyController interface {
+	CameraController
+
+	SetVolumeContext(ctx context.Context, volume int) (*EventStreamResponse, error)
+	MuteContext(ctx context.Context) (*EventStreamResponse, error)
+	UnMuteContext(ctx context.Context) (*EventStreamResponse, error)
+	PlayContext(ctx context.Context, trackId string, position int) error
+	PauseContext(ctx context.Context) error
+	NextContext(ctx context.Context) error
+	ShuffleContext(ctx context.Context, on bool) (*EventStreamResponse, error)
+	ContinuousContext(ctx context.Context) (*EventStreamResponse, error)
+	SingleTrackContext(ctx context.Context) (*EventStreamResponse, error)
+	SetLoopBackModeContext(ctx context.Context, loopbackMode string) (*EventStreamResponse, error)
+	GetAudioPlaybackContext(ctx context.Context) (*AudioPlaybackState, error)
+	EnableSleepTimerContext(ctx context.Context, sleepTime int64, sleepTimeRel int) (*EventStreamResponse, error)
+	DisableSleepTimerContext(ctx context.Context, sleepTimeRel int) (*EventStreamResponse, error)
+	GetNightLightContext(ctx context.Context) (*NightLightState, error)
+	NightLightContext(ctx context.Context, on bool) (*EventStreamResponse, error)
+	SetNightLightBrightnessContext(ctx context.Context, level int) (*EventStreamResponse, error)
+	SetNightLightModeContext(ctx context.Context, mode string) (*EventStreamResponse, error)
+	SetNightLightColorContext(ctx context.Context, red, blue, green int) (*EventStreamResponse, error)
+	EnableNightLightTimerContext(ctx context.Context, sleepTime int64, sleepTimeRel int) (*EventStreamResponse, error)
+	DisableNightLightTimerContext(ctx context.Context, sleepTimeRel int) (*EventStreamResponse, error)
+}
+
+// BasestationController controls a basestation, and its connection to the event stream.
+type BasestationController interface {
+	IsConnected() error
+	SubscribeContext(ctx context.Context) error
+	UnsubscribeContext(ctx context.Context) error
+	Disconnect() error
+	PingContext(ctx context.Context) error
+	NotifyEventStreamContext(ctx context.Context, payload EventStreamPayload, msg string) error
+	GetStateContext(ctx context.Context) (*BasestationState, error)
+	GetAssociatedCamerasStateContext(ctx context.Context) ([]CameraState, error)
+	GetRulesContext(ctx context.Context) (*RulesList, error)
+	GetCalendarModeContext(ctx context.Context) (*ScheduleState, error)
+	SetCalendarModeContext(ctx context.Context, active bool) (*EventStreamResponse, error)
+	GetModesContext(ctx context.Context) (*ModesList, error)
+	SetCustomModeContext(ctx context.Context, mode string) (*EventStreamResponse, error)
+	DeleteModeContext(ctx context.Context, mode string) (*EventStreamResponse, error)
+	ArmContext(ctx context.Context) (*EventStreamResponse, error)
+	DisarmContext(ctx context.Context) (*EventStreamResponse, error)
+	SirenOnContext(ctx context.Context) (*EventStreamResponse, error)
+	SirenOffContext(ctx context.Context) (*EventStreamResponse, error)
+}
+
+// LightController controls a light.
+type LightController interface {
+	GetStateContext(ctx context.Context) (*EventStreamResponse, error)
+	OnContext(ctx context.Context) (*EventStreamResponse, error)
+	OffContext(ctx context.Context) (*EventStreamResponse, error)
+	SetBrightnessContext(ctx context.Context, brightness int) (*EventStreamResponse, error)
+	SetColorTemperatureContext(ctx context.Context, kelvin int) (*EventStreamResponse, error)
+	SetColorContext(ctx context.Context, red, green, blue int) (*EventStreamResponse, error)
+	SetDurationContext(ctx context.Context, seconds int) (*EventStreamResponse, error)
+	SetMotionActivationContext(ctx context.Context, enabled bool, sensitivity int, duration int) (*EventStreamResponse, error)
+	SetFlashContext(ctx context.Context, pattern string) (*EventStreamResponse, error)
+}
+
+// SirenController controls a siren, either a standalone one or the built-in siren of a basestation.
+type SirenController interface {
+	GetStateContext(ctx context.Context) (*EventStreamResponse, error)
+	OnContext(ctx context.Context, options SirenOptions) (*EventStreamResponse, error)
+	OffContext(ctx context.Context) (*EventStreamResponse, error)
+}
+
+// DoorbellController controls a doorbell.
+type DoorbellController interface {
+	GetStateContext(ctx context.Context) (*EventStreamResponse, error)
+}
+
+// ChimeController controls a chime.
+type ChimeController interface {
+	GetStateContext(ctx context.Context) (*EventStreamResponse, error)
+}
+
+// LibraryService manages the recordings in the library.
+type LibraryService interface {
+	GetLibraryMetaDataContext(ctx context.Context, fromDate, toDate time.Time) (*LibraryMetaData, error)
+	GetLibraryContext(ctx context.Context, fromDate, toDate time.Time) (*Library, error)
+	DeleteRecordingContext(ctx context.Context, r *Recording) error
+	BatchDeleteRecordingsContext(ctx context.Context, l *Library) error
+	SendAnalyticFeedbackContext(ctx context.Context, r *Recording) error
+	DownloadFileContext(ctx context.Context, url string, w io.Writer) error
+}
+
+// AccountService manages the account and its session.
+type AccountService interface {
+	LogoutContext(ctx context.Context) error
+	CheckSessionContext(ctx context.Context) (*Session, error)
+	GetProfileContext(ctx context.Context) (*UserProfile, error)
+	UpdateDisplayOrderContext(ctx context.Context, d DeviceOrder) error
+	UpdateProfileContext(ctx context.Context, firstName, lastName string) error
+	UpdatePasswordContext(ctx context.Context, pass string) error
+	UpdateFriendsContext(ctx context.Context, f Friend) error
+}
+
+// DeviceService gets the devices of the account.
+type DeviceService interface {
+	GetDevicesContext(ctx context.Context) (*Devices, error)
+	RefreshDevicesContext(ctx context.Context) ([]DeviceEvent, error)
+}
+
+var (
+	_ CameraController      = (*Camera)(nil)
+	_ ArloBabyController    = (*Camera)(nil)
+	_ BasestationController = (*Basestation)(nil)
+	_ BasestationController = (*ArloQ)(nil)
+	_ CameraController      = (*ArloQ)(nil)
+	_ BasestationController = (*Bridge)(nil)
+	_ DoorbellController    = (*Doorbell)(nil)
+	_ ChimeController       = (*Chime)(nil)
+	_ LightController       = (*Light)(nil)
+	_ SirenController       = (*Siren)(nil)
+	_ LibraryService        = (*Arlo)(nil)
+	_ AccountService        = (*Arlo)(nil)
+	_ DeviceService         = (*Arlo)(nil)
+)
@@ -18,12 +18,16 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -32,10 +36,29 @@ import (
 )
 
 type Client struct {
+	// These are first so they're 64-bit aligned for atomic.
+	authGeneration uint64 // Incremented every time reauthentication succeeds.
+	stats          Stats
+
 	BaseURL     *url.URL
 	BaseHeaders *http.Header
 	HttpClient  *http.Client
 	rwmutex     sync.RWMutex
+	retryPolicy RetryPolicy
+	limiter     *Limiter
+
+	// Reauthenticate, if set, is called when a request is rejected with 401 Unauthorized.
+	// If it succeeds, the request is sent again. Requests made with a context from WithoutReauthentication never trigger it.
+	Reauthenticate func(ctx context.Context) error
+	authMutex      sync.Mutex // Makes sure only one request at a time reauthenticates.
+}
+
+type noReauthenticationKey struct{}
+
+// WithoutReauthentication returns a context that makes requests fail on 401 Unauthorized, instead of reauthenticating.
+// Use it for the requests made by Reauthenticate itself.
+func WithoutReauthentication(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noReauthenticationKey{}, true)
 }
 
 func NewClient(baseURL string, baseHeaders http.Header) (*Client, error) {
@@ -62,6 +85,7 @@ func NewClient(baseURL string, baseHeaders http.Header) (*Client, error) {
 		BaseURL:     u,
 		BaseHeaders: &header,
 		HttpClient:  &http.Client{Jar: jar, Timeout: 30 * time.Second},
+		retryPolicy: DefaultRetryPolicy,
 	}, nil
 }
 
@@ -72,30 +96,69 @@ func (c *Client) AddHeader(key, value string) {
 }
 
 func (c *Client) Get(uri string, header http.Header) (*Response, error) {
-	req, err := c.newRequest("GET", uri, nil, header)
-	if err != nil {
-		return nil, errors.WithMessage(err, "get request "+uri+" failed")
-	}
-	return c.do(req)
+	return c.GetContext(context.Background(), uri, header)
+}
+
+func (c *Client) GetContext(ctx context.Context, uri string, header http.Header) (*Response, error) {
+	return c.send(ctx, "GET", uri, nil, header, "get request "+uri+" failed")
 }
 
 func (c *Client) Post(uri string, body interface{}, header http.Header) (*Response, error) {
-	req, err := c.newRequest("POST", uri, body, header)
-	if err != nil {
-		return nil, errors.WithMessage(err, "post request "+uri+" failed")
-	}
-	return c.do(req)
+	return c.PostContext(context.Background(), uri, body, header)
+}
+
+func (c *Client) PostContext(ctx context.Context, uri string, body interface{}, header http.Header) (*Response, error) {
+	return c.send(ctx, "POST", uri, body, header, "post request "+uri+" failed")
 }
 
 func (c *Client) Put(uri string, body interface{}, header http.Header) (*Response, error) {
-	req, err := c.newRequest("PUT", uri, body, header)
-	if err != nil {
-		return nil, errors.WithMessage(err, "put request "+uri+" failed")
+	return c.PutContext(context.Background(), uri, body, header)
+}
+
+func (c *Client) PutContext(ctx context.Context, uri string, body interface{}, header http.Header) (*Response, error) {
+	return c.send(ctx, "PUT", uri, body, header, "put request "+uri+" failed")
+}
+
+// send makes a request, retrying it according to the retry policy.
+// If it's rejected with 401 Unauthorized, it reauthenticates and sends it once more.
+func (c *Client) send(ctx context.Context, method string, uri string, body interface{}, header http.Header, msg string) (*Response, error) {
+	generation := atomic.LoadUint64(&c.authGeneration)
+
+	resp, err := c.doWithRetry(ctx, method, uri, body, header, msg)
+	if !IsUnauthorized(err) || !c.canReauthenticate(ctx) {
+		return resp, err
 	}
-	return c.do(req)
+
+	if err := c.reauthenticate(ctx, generation); err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	// The request is built again, so it picks up the new auth headers.
+	return c.doWithRetry(ctx, method, uri, body, header, msg)
 }
 
-func (c *Client) newRequest(method string, uri string, body interface{}, header http.Header) (*Request, error) {
+func (c *Client) canReauthenticate(ctx context.Context) bool {
+	return c.Reauthenticate != nil && ctx.Value(noReauthenticationKey{}) == nil
+}
+
+// reauthenticate calls Reauthenticate, unless another request already did since generation.
+func (c *Client) reauthenticate(ctx context.Context, generation uint64) error {
+	c.authMutex.Lock()
+	defer c.authMutex.Unlock()
+
+	if atomic.LoadUint64(&c.authGeneration) != generation {
+		return nil
+	}
+
+	if err := c.Reauthenticate(WithoutReauthentication(ctx)); err != nil {
+		return errors.WithMessage(err, "failed to reauthenticate")
+	}
+
+	atomic.AddUint64(&c.authGeneration, 1)
+	return nil
+}
+
+func (c *Client) newRequest(ctx context.Context, method string, uri string, body interface{}, header http.Header) (*Request, error) {
 
 	var buf io.ReadWriter
 	if body != nil {
@@ -112,17 +175,16 @@ func (c *Client) newRequest(method string, uri string, body interface{}, header
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create request object")
 	}
+	req = req.WithContext(ctx)
 
 	c.rwmutex.RLock()
-	baseHeaders := *c.BaseHeaders
-	c.rwmutex.RUnlock()
-
-	for k, v := range baseHeaders {
+	for k, v := range *c.BaseHeaders {
 		for _, h := range v {
 			//log.Printf("Adding header (%s): (%s - %s)\n\n", u, k, h)
 			req.Header.Set(k, h)
 		}
 	}
+	c.rwmutex.RUnlock()
 
 	for k, v := range header {
 		for _, h := range v {
@@ -155,7 +217,21 @@ func (c *Client) do(req *Request) (*Response, error) {
 
 	if resp.StatusCode >= http.StatusBadRequest {
 		defer resp.Body.Close()
-		return nil, errors.New("http request failed with status: " + resp.Status)
+		return nil, newAPIError(resp)
+	}
+
+	// An expired session isn't always a 401, so the JSON responses are checked for the error code too, so they get to
+	// reauthenticate. The body is put back for the caller to decode.
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/json" {
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read response body")
+		}
+		if e := expiredSession(resp, b); e != nil {
+			return nil, e
+		}
+		resp.Body = ioutil.NopCloser(bytes.NewReader(b))
 	}
 
 	return c.newResponse(resp)
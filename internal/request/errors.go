@@ -0,0 +1,188 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize is how much of the body of a failed response is read to find out why it failed.
+const maxErrorBodySize = 64 * 1024
+
+// APIError is returned when the Arlo API rejects a request, either with an http error status, or with a response that
+// says it didn't succeed. Use errors.As to get at it, or one of the Is* helpers to check for common failures.
+type APIError struct {
+	StatusCode int    // The http status code of the response.
+	Code       string // The Arlo error code, if there was one.
+	Reason     string
+	Message    string
+	Endpoint   string // The method and path of the request, e.g. "POST /hmsweb/users/devices/notify/XXXXXXXXXXXXX".
+	TransId    string // The transId of the event stream message, if the request was one.
+	// RetryAfter is how long the server asked to wait before trying again, if it did.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	var msg string
+	if e.StatusCode >= http.StatusBadRequest {
+		msg = "http request failed with status: " + strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+	} else {
+		msg = "request failed"
+	}
+
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	} else if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	if e.Code != "" {
+		msg += " (error " + e.Code + ")"
+	}
+
+	return msg
+}
+
+// APIError returns an APIError for a response that says the request didn't succeed.
+// Anything left empty is filled in from the body read by Decode, if it has it.
+func (resp *Response) APIError(code, reason, message string) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Code:       code,
+		Reason:     reason,
+		Message:    message,
+		Endpoint:   endpoint(resp.Request),
+	}
+	e.parse(resp.body)
+	return e
+}
+
+func endpoint(req *http.Request) string {
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	return req.Method + " " + req.URL.Path
+}
+
+// newAPIError builds an APIError from a response with an http error status.
+func newAPIError(resp *http.Response) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint(resp.Request),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	if b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize)); err == nil {
+		e.parse(b)
+	}
+
+	return e
+}
+
+// parse fills in the empty fields of the error from the body of the response.
+// Both of the error formats used by the Arlo API are understood, {"data": {...}} and {"meta": {...}}.
+func (e *APIError) parse(b []byte) {
+	var body struct {
+		Data struct {
+			Error   string `json:"error"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"data"`
+		Meta struct {
+			Error   int    `json:"error"`
+			Message string `json:"message"`
+		} `json:"meta"`
+	}
+
+	// The data isn't always an object, in which case there's nothing to find in it.
+	if len(b) == 0 || json.Unmarshal(b, &body) != nil {
+		return
+	}
+
+	if body.Meta.Error != 0 {
+		body.Data.Error = strconv.Itoa(body.Meta.Error)
+	}
+	if body.Meta.Message != "" {
+		body.Data.Message = body.Meta.Message
+	}
+
+	if e.Code == "" {
+		e.Code = body.Data.Error
+	}
+	if e.Reason == "" {
+		e.Reason = body.Data.Reason
+	}
+	if e.Message == "" {
+		e.Message = body.Data.Message
+	}
+}
+
+// sessionExpired is the Arlo error code of a session that expired. It's sometimes sent with a 200 OK status, in a
+// response that says it didn't succeed, rather than with 401 Unauthorized.
+const sessionExpired = "2015"
+
+// IsUnauthorized returns true if err is because the auth token was rejected.
+func IsUnauthorized(err error) bool {
+	var e *APIError
+	return errors.As(err, &e) && (e.StatusCode == http.StatusUnauthorized || e.Code == sessionExpired)
+}
+
+// expiredSession returns an APIError if the body of a successful response says that the session expired.
+func expiredSession(resp *http.Response, b []byte) *APIError {
+	var body struct {
+		Success *bool `json:"success"`
+	}
+	if json.Unmarshal(b, &body) != nil || body.Success == nil || *body.Success {
+		return nil
+	}
+
+	e := &APIError{StatusCode: resp.StatusCode, Endpoint: endpoint(resp.Request)}
+	e.parse(b)
+	if e.Code != sessionExpired {
+		return nil
+	}
+	return e
+}
+
+// IsRateLimited returns true if err is because too many requests were made.
+func IsRateLimited(err error) bool {
+	var e *APIError
+	return errors.As(err, &e) && e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsDeviceOffline returns true if err is because the device the request was for is offline.
+// Arlo doesn't have a dedicated status for this, so it's based on the reason given.
+func IsDeviceOffline(err error) bool {
+	var e *APIError
+	if !errors.As(err, &e) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(e.Reason+" "+e.Message), "offline")
+}
+
+// IsTimeout returns true if err is because a request, or the wait for its response, timed out.
+func IsTimeout(err error) bool {
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func newResponse(statusCode int, body string) *http.Response {
+	u, _ := url.Parse("https://example.com/hmsweb/users/devices/notify/BASESTATION1")
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    &http.Request{Method: http.MethodPost, URL: u},
+	}
+}
+
+const testEndpoint = "POST /hmsweb/users/devices/notify/BASESTATION1"
+
+func TestNewAPIError(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		statusCode int
+		body       string
+		want       APIError
+	}{
+		{"data", http.StatusBadRequest, `{"success": false, "data": {"error": "2059", "reason": "Device is offline.", "message": "offline"}}`,
+			APIError{StatusCode: http.StatusBadRequest, Code: "2059", Reason: "Device is offline.", Message: "offline", Endpoint: testEndpoint}},
+		{"meta", http.StatusUnauthorized, `{"meta": {"code": 401, "error": 2015, "message": "Your session has expired."}}`,
+			APIError{StatusCode: http.StatusUnauthorized, Code: "2015", Message: "Your session has expired.", Endpoint: testEndpoint}},
+		{"data isn't an object", http.StatusInternalServerError, `{"success": false, "data": "oops"}`,
+			APIError{StatusCode: http.StatusInternalServerError, Endpoint: testEndpoint}},
+		{"not json", http.StatusBadGateway, `<html>Bad Gateway</html>`,
+			APIError{StatusCode: http.StatusBadGateway, Endpoint: testEndpoint}},
+		{"empty", http.StatusServiceUnavailable, ``,
+			APIError{StatusCode: http.StatusServiceUnavailable, Endpoint: testEndpoint}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := newAPIError(newResponse(test.statusCode, test.body)); *got != test.want {
+				t.Errorf("got %+v, want %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+func TestAPIErrorKeepsFields(t *testing.T) {
+	resp := &Response{
+		Response: *newResponse(http.StatusOK, ""),
+		body:     []byte(`{"success": false, "data": {"error": "2059", "reason": "Device is offline.", "message": "offline"}}`),
+	}
+
+	// Fields that are given aren't overwritten by the ones in the body.
+	got := resp.APIError("", "No such device.", "")
+	want := APIError{StatusCode: http.StatusOK, Code: "2059", Reason: "No such device.", Message: "offline", Endpoint: testEndpoint}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+}
+
+func TestExpiredSession(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"data", `{"success": false, "data": {"error": "2015", "reason": "Your session has expired."}}`, true},
+		{"meta", `{"success": false, "meta": {"error": 2015, "message": "Your session has expired."}}`, true},
+		{"other error", `{"success": false, "data": {"error": "2059", "reason": "Device is offline."}}`, false},
+		{"success", `{"success": true, "data": {"error": "2015"}}`, false},
+		{"no success", `{"data": {"error": "2015"}}`, false},
+		{"not json", `ok`, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resp := newResponse(http.StatusOK, "")
+			e := expiredSession(resp, []byte(test.body))
+			if got := e != nil; got != test.want {
+				t.Fatalf("got %v, want %v", e, test.want)
+			}
+			if e != nil && (e.Code != sessionExpired || e.StatusCode != http.StatusOK || e.Endpoint != testEndpoint) {
+				t.Errorf("got %+v", *e)
+			}
+		})
+	}
+}
+
+type timeoutError struct{ timeout bool }
+
+func (e timeoutError) Error() string { return "i/o timeout" }
+func (e timeoutError) Timeout() bool { return e.timeout }
+
+func TestIs(t *testing.T) {
+	wrap := func(err error) error { return errors.WithMessage(err, "failed to do something") }
+
+	for _, test := range []struct {
+		name string
+		is   func(error) bool
+		err  error
+		want bool
+	}{
+		{"unauthorized status", IsUnauthorized, &APIError{StatusCode: http.StatusUnauthorized}, true},
+		{"unauthorized code", IsUnauthorized, &APIError{StatusCode: http.StatusOK, Code: sessionExpired}, true},
+		{"unauthorized wrapped", IsUnauthorized, wrap(&APIError{StatusCode: http.StatusUnauthorized}), true},
+		{"unauthorized forbidden", IsUnauthorized, &APIError{StatusCode: http.StatusForbidden}, false},
+		{"unauthorized not an APIError", IsUnauthorized, errors.New("401"), false},
+		{"rate limited", IsRateLimited, &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"rate limited wrapped", IsRateLimited, wrap(&APIError{StatusCode: http.StatusTooManyRequests}), true},
+		{"rate limited unavailable", IsRateLimited, &APIError{StatusCode: http.StatusServiceUnavailable}, false},
+		{"offline reason", IsDeviceOffline, &APIError{Reason: "Device is OFFLINE."}, true},
+		{"offline message", IsDeviceOffline, wrap(&APIError{Message: "The device is offline"}), true},
+		{"offline other", IsDeviceOffline, &APIError{Reason: "Device is busy."}, false},
+		{"offline not an APIError", IsDeviceOffline, errors.New("device is offline"), false},
+		{"timeout", IsTimeout, timeoutError{true}, true},
+		{"timeout wrapped", IsTimeout, wrap(fmt.Errorf("read: %w", timeoutError{true})), true},
+		{"timeout deadline", IsTimeout, wrap(context.DeadlineExceeded), true},
+		{"timeout false", IsTimeout, timeoutError{false}, false},
+		{"timeout canceled", IsTimeout, context.Canceled, false},
+		{"nil", IsTimeout, nil, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.is(test.err); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAPIErrorRetryAfter(t *testing.T) {
+	resp := newResponse(http.StatusTooManyRequests, "")
+	resp.Header.Set("Retry-After", "3")
+	if got := newAPIError(resp).RetryAfter; got != 3*time.Second {
+		t.Errorf("got %v, want %v", got, 3*time.Second)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package request
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// A Limiter limits how often, and how many at once, requests are made.
+// The rate is enforced with a token bucket, and the number of requests in flight with a semaphore.
+// Requests over the limits wait their turn instead of failing. A nil Limiter doesn't limit anything.
+type Limiter struct {
+	rate     float64 // Tokens added per second, 0 means no rate limit.
+	burst    float64 // Size of the bucket.
+	tokens   float64
+	last     time.Time
+	mutex    sync.Mutex
+	inFlight chan struct{} // nil means no limit on requests in flight.
+}
+
+// NewLimiter returns a Limiter that allows rate requests per second, in bursts of up to burst requests, with no more than
+// maxInFlight of them at once. A rate or maxInFlight of 0 disables that limit.
+func NewLimiter(rate float64, burst int, maxInFlight int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+
+	if maxInFlight > 0 {
+		l.inFlight = make(chan struct{}, maxInFlight)
+	}
+
+	return l
+}
+
+// Acquire waits until a request can be made, or ctx is done. If it succeeds, release must be called once the request is
+// done, which is when its response body is closed.
+func (l *Limiter) Acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release = func() {
+		if l.inFlight != nil {
+			<-l.inFlight
+		}
+	}
+
+	if err := l.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// wait takes a token from the bucket, waiting for one to be added if it's empty.
+func (l *Limiter) wait(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	l.mutex.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// Take the token now, even if it isn't there yet, so the requests that are waiting are served in order.
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mutex.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Give back the token, since it won't be used.
+		l.mutex.Lock()
+		l.tokens++
+		l.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// releaseOnClose is the body of a response, which releases the request's turn from the limiter when it's closed.
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// SetLimiter sets the limiter that every request made by the client waits on. A nil limiter disables it.
+func (c *Client) SetLimiter(limiter *Limiter) {
+	c.rwmutex.Lock()
+	defer c.rwmutex.Unlock()
+	c.limiter = limiter
+}
+
+func (c *Client) Limiter() *Limiter {
+	c.rwmutex.RLock()
+	defer c.rwmutex.RUnlock()
+	return c.limiter
+}
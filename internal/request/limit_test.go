@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// acquireWithin tries to acquire a turn from l within d, and releases it right away if it succeeds.
+func acquireWithin(l *Limiter, d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	release, err := l.Acquire(ctx)
+	if err == nil {
+		release()
+	}
+	return err
+}
+
+func TestLimiterNil(t *testing.T) {
+	var l *Limiter
+	if err := acquireWithin(l, time.Millisecond); err != nil {
+		t.Errorf("Acquire() on a nil Limiter = %v, want nil", err)
+	}
+}
+
+func TestLimiterInFlight(t *testing.T) {
+	l := NewLimiter(0, 1, 1)
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := acquireWithin(l, 50*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("Acquire() with a request in flight = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	release()
+	if err := acquireWithin(l, 50*time.Millisecond); err != nil {
+		t.Errorf("Acquire() after release = %v, want nil", err)
+	}
+}
+
+func TestLimiterRate(t *testing.T) {
+	l := NewLimiter(20, 2, 0)
+
+	// The burst goes through at once, then the requests are 50ms apart.
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := acquireWithin(l, time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("4 requests took %s, want at least 100ms at 20 per second with a burst of 2", elapsed)
+	}
+
+	// A request that gives up waiting gives back its token.
+	l = NewLimiter(1, 1, 0)
+	if err := acquireWithin(l, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := acquireWithin(l, 10*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("Acquire() with an empty bucket = %v, want %v", err, context.DeadlineExceeded)
+	}
+	l.mutex.Lock()
+	tokens := l.tokens
+	l.mutex.Unlock()
+	if tokens < -0.1 {
+		t.Errorf("tokens = %f after a canceled Acquire(), want the token back", tokens)
+	}
+}
+
+func TestLimiterReleasedOnClose(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL, fastRetryPolicy)
+	l := NewLimiter(0, 1, 1)
+	c.SetLimiter(l)
+
+	resp, err := c.Get("/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The request is still in flight until its body is closed.
+	if err := acquireWithin(l, 50*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("Acquire() before the body is closed = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Closing the body twice only frees the slot once.
+	resp.Body.Close()
+	resp.Body.Close()
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	if err := acquireWithin(l, 50*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("Acquire() with the slot taken = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -19,6 +19,7 @@ package request
 import (
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"log"
 	"mime"
 	"net/http"
@@ -30,6 +31,7 @@ import (
 
 type Response struct {
 	http.Response
+	body []byte // The body read by Decode, kept so APIError can find out why a request failed.
 }
 
 func (resp *Response) GetContentType() (string, error) {
@@ -52,8 +54,13 @@ func (resp *Response) Decode(s interface{}) error {
 
 	switch mediaType {
 	case "application/json":
-		err := json.NewDecoder(resp.Body).Decode(&s)
+		b, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
+			return errors.Wrap(err, "failed to read response body")
+		}
+		resp.body = b
+
+		if err := json.Unmarshal(b, &s); err != nil {
 			return errors.Wrap(err, "failed to create "+reflect.TypeOf(s).String()+" object")
 		}
 	default:
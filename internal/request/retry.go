@@ -0,0 +1,230 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package request
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeffreydwalter/arlo-go/internal/util"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy decides whether, and when, a failed request is sent again.
+//
+// Requests are retried on connection errors, 429 Too Many Requests and 5xx statuses. Only idempotent methods (GET, PUT,
+// DELETE, etc.) are retried after the request may have reached the server; POST requests are only retried when they were
+// rate limited or never sent, unless RetryNonIdempotent is set.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Less than 2 disables retries.
+	MaxAttempts int
+	// MinBackoff and MaxBackoff bound the exponential backoff (with jitter) between attempts. MaxBackoff also caps how
+	// long a Retry-After from the server is waited for.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// RetryNonIdempotent allows POST requests to be retried after errors where they may have reached the server.
+	RetryNonIdempotent bool
+	// Overrides replaces the policy for requests whose uri starts with the key, e.g. "/users/devices/notify/".
+	// The longest matching key wins.
+	Overrides map[string]RetryPolicy
+}
+
+// DefaultRetryPolicy is the RetryPolicy used by a new Client.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// forURI returns the policy that applies to uri.
+func (p RetryPolicy) forURI(uri string) RetryPolicy {
+	match := ""
+	policy := p
+	for prefix, override := range p.Overrides {
+		if strings.HasPrefix(uri, prefix) && len(prefix) > len(match) {
+			match = prefix
+			policy = override
+		}
+	}
+	return policy
+}
+
+// retryable returns true if a request that failed with err should be sent again.
+func (p RetryPolicy) retryable(method string, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			// The request was turned away, so it's safe to send again whatever the method.
+			return true
+		case apiErr.StatusCode >= http.StatusInternalServerError:
+			return p.RetryNonIdempotent || idempotent(method)
+		default:
+			return false
+		}
+	}
+
+	// Don't retry when the caller gave up.
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	// The connection couldn't be made, so the request never reached the server.
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	return p.RetryNonIdempotent || idempotent(method)
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+// delay returns how long to wait before the attempt after attempt (which starts at 0).
+// A Retry-After from the server takes precedence over the backoff, but it's no longer than MaxBackoff either.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if p.MaxBackoff > 0 && apiErr.RetryAfter > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		return apiErr.RetryAfter
+	}
+	return util.Backoff(attempt, p.MinBackoff, p.MaxBackoff)
+}
+
+// parseRetryAfter parses a Retry-After header, which is either a number of seconds or an http date.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Stats are counters of the requests made by a Client.
+type Stats struct {
+	Requests uint64 // Every attempt at a request, including retries.
+	Retries  uint64 // Attempts that were retries of a failed attempt.
+	Failures uint64 // Requests that failed, after any retries.
+}
+
+// Add returns the sum of s and o.
+func (s Stats) Add(o Stats) Stats {
+	return Stats{
+		Requests: s.Requests + o.Requests,
+		Retries:  s.Retries + o.Retries,
+		Failures: s.Failures + o.Failures,
+	}
+}
+
+// SetRetryPolicy replaces the retry policy of the client.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.rwmutex.Lock()
+	defer c.rwmutex.Unlock()
+	c.retryPolicy = policy
+}
+
+func (c *Client) RetryPolicy() RetryPolicy {
+	c.rwmutex.RLock()
+	defer c.rwmutex.RUnlock()
+	return c.retryPolicy
+}
+
+// Stats returns a snapshot of the counters of the requests made by the client.
+func (c *Client) Stats() Stats {
+	return Stats{
+		Requests: atomic.LoadUint64(&c.stats.Requests),
+		Retries:  atomic.LoadUint64(&c.stats.Retries),
+		Failures: atomic.LoadUint64(&c.stats.Failures),
+	}
+}
+
+// doWithRetry makes a request, retrying it according to the retry policy.
+func (c *Client) doWithRetry(ctx context.Context, method string, uri string, body interface{}, header http.Header, msg string) (*Response, error) {
+	policy := c.RetryPolicy().forURI(uri)
+	limiter := c.Limiter()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			atomic.AddUint64(&c.stats.Retries, 1)
+		}
+		atomic.AddUint64(&c.stats.Requests, 1)
+
+		// Build the request for every attempt, so it picks up any changes to the base headers.
+		req, err := c.newRequest(ctx, method, uri, body, header)
+		if err != nil {
+			return nil, errors.WithMessage(err, msg)
+		}
+
+		// Every attempt waits its turn, so retries count against the rate limit too.
+		release, err := limiter.Acquire(ctx)
+		if err != nil {
+			atomic.AddUint64(&c.stats.Failures, 1)
+			return nil, errors.WithMessage(err, msg)
+		}
+
+		// The request is in flight until its body is read, so the slot is only freed when the body is closed.
+		resp, err := c.do(req)
+		if err == nil {
+			resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+			return resp, nil
+		}
+		release()
+
+		// A caller that gave up while the request was being made doesn't get a retry, whatever the error was.
+		if attempt+1 >= policy.MaxAttempts || ctx.Err() != nil || !policy.retryable(method, err) {
+			atomic.AddUint64(&c.stats.Failures, 1)
+			return nil, err
+		}
+
+		delay := policy.delay(attempt, err)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			// There's no point waiting if the caller will have given up by then.
+			atomic.AddUint64(&c.stats.Failures, 1)
+			return nil, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			atomic.AddUint64(&c.stats.Failures, 1)
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newFailingServer returns a server that answers the first failures requests with statusCode and the Retry-After
+// header, if it isn't "", and the rest with 200 OK. It counts the requests in n.
+func newFailingServer(failures int32, statusCode int, retryAfter string, n *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(n, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(statusCode)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func newTestClient(t *testing.T, url string, policy RetryPolicy) *Client {
+	t.Helper()
+	c, err := NewClient(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetRetryPolicy(policy)
+	return c
+}
+
+var fastRetryPolicy = RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestRetry(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		statusCode int
+		method     string
+		failures   int32
+		wantErr    bool
+		want       Stats
+	}{
+		{"get after 503", http.StatusServiceUnavailable, "GET", 2, false, Stats{Requests: 3, Retries: 2}},
+		{"post after 429", http.StatusTooManyRequests, "POST", 1, false, Stats{Requests: 2, Retries: 1}},
+		{"post after 503", http.StatusServiceUnavailable, "POST", 1, true, Stats{Requests: 1, Failures: 1}},
+		{"too many failures", http.StatusServiceUnavailable, "GET", 3, true, Stats{Requests: 3, Retries: 2, Failures: 1}},
+		{"not found", http.StatusNotFound, "GET", 1, true, Stats{Requests: 1, Failures: 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var n int32
+			srv := newFailingServer(test.failures, test.statusCode, "", &n)
+			defer srv.Close()
+			c := newTestClient(t, srv.URL, fastRetryPolicy)
+
+			var err error
+			if test.method == "POST" {
+				_, err = c.Post("/", map[string]string{}, nil)
+			} else {
+				_, err = c.Get("/", nil)
+			}
+			if (err != nil) != test.wantErr {
+				t.Errorf("err = %v, want an error %t", err, test.wantErr)
+			}
+			if got := c.Stats(); got != test.want {
+				t.Errorf("Stats() = %+v, want %+v", got, test.want)
+			}
+			if got := atomic.LoadInt32(&n); uint64(got) != test.want.Requests {
+				t.Errorf("server got %d requests, want %d", got, test.want.Requests)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyOverrides(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		Overrides: map[string]RetryPolicy{
+			"/users/":               {MaxAttempts: 2},
+			"/users/devices/notify": {MaxAttempts: 1},
+		},
+	}
+
+	for uri, want := range map[string]int{
+		"/users/session":                    2,
+		"/users/devices/notify/BASESTATION": 1,
+		"/login":                            3,
+	} {
+		if got := policy.forURI(uri).MaxAttempts; got != want {
+			t.Errorf("forURI(%q).MaxAttempts = %d, want %d", uri, got, want)
+		}
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for i := 0; i < 10; i++ {
+			if d := policy.delay(attempt, nil); d < max/2 || d > max {
+				t.Errorf("delay(%d) = %s, want between %s and %s", attempt, d, max/2, max)
+			}
+		}
+	}
+
+	if d := policy.delay(0, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 500 * time.Millisecond}); d != 500*time.Millisecond {
+		t.Errorf("delay() with a Retry-After of 500ms = %s, want 500ms", d)
+	}
+	if d := policy.delay(0, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Hour}); d != time.Second {
+		t.Errorf("delay() with a Retry-After of 1h = %s, want MaxBackoff", d)
+	}
+}
+
+func TestRetryAfterCapped(t *testing.T) {
+	var n int32
+	srv := newFailingServer(1, http.StatusTooManyRequests, "3600", &n)
+	defer srv.Close()
+	c := newTestClient(t, srv.URL, fastRetryPolicy)
+
+	start := time.Now()
+	if _, err := c.Get("/", nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Get() took %s, want the Retry-After capped at MaxBackoff", elapsed)
+	}
+	if got := c.Stats(); got != (Stats{Requests: 2, Retries: 1}) {
+		t.Errorf("Stats() = %+v, want 2 requests and 1 retry", got)
+	}
+}
+
+func TestRetryContextDone(t *testing.T) {
+	var n int32
+	srv := newFailingServer(1, http.StatusServiceUnavailable, "", &n)
+	defer srv.Close()
+	c := newTestClient(t, srv.URL, RetryPolicy{MaxAttempts: 3, MinBackoff: time.Minute, MaxBackoff: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := c.GetContext(ctx, "/", nil); err == nil {
+		t.Fatal("GetContext() succeeded, want an error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("GetContext() took %s, want it to stop when ctx is done", elapsed)
+	}
+	if got := c.Stats(); got != (Stats{Requests: 1, Failures: 1}) {
+		t.Errorf("Stats() = %+v, want 1 request and 1 failure", got)
+	}
+}
+
+func TestStatsAdd(t *testing.T) {
+	got := Stats{Requests: 3, Retries: 1}.Add(Stats{Requests: 2, Failures: 1})
+	if want := (Stats{Requests: 5, Retries: 1, Failures: 1}); got != want {
+		t.Errorf("Add() = %+v, want %+v", got, want)
+	}
+}
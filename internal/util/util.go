@@ -19,8 +19,10 @@ package util
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 func PrettyPrint(data interface{}) string {
@@ -92,3 +94,15 @@ func HeaderToMap(header http.Header) map[string]string {
 	}
 	return h
 }
+
+// Backoff returns how long to wait before retry number attempt (starting at 0). The delay doubles with every attempt,
+// starting at min and capped at max, and is then randomized to between half and all of it so clients don't retry in lockstep.
+func Backoff(attempt int, min, max time.Duration) time.Duration {
+	d := max
+	if attempt < 32 {
+		if e := min << uint(attempt); e > 0 && e < max {
+			d = e
+		}
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -17,6 +17,7 @@
 package arlo
 
 import (
+	"context"
 	"time"
 
 	"github.com/pkg/errors"
@@ -54,10 +55,15 @@ type Recording struct {
 type Library []Recording
 
 func (a *Arlo) GetLibraryMetaData(fromDate, toDate time.Time) (libraryMetaData *LibraryMetaData, err error) {
+	return a.GetLibraryMetaDataContext(context.Background(), fromDate, toDate)
+}
+
+// GetLibraryMetaDataContext is like GetLibraryMetaData, but uses ctx for the request.
+func (a *Arlo) GetLibraryMetaDataContext(ctx context.Context, fromDate, toDate time.Time) (libraryMetaData *LibraryMetaData, err error) {
 	msg := "failed to get library metadata"
 
 	body := map[string]string{"dateFrom": fromDate.Format("20060102"), "dateTo": toDate.Format("20060102")}
-	resp, err := a.post(MetadataUri, "", body, nil)
+	resp, err := a.post(ctx, MetadataUri, "", body, nil)
 	if err != nil {
 		return nil, errors.WithMessage(err, msg)
 	}
@@ -69,17 +75,22 @@ func (a *Arlo) GetLibraryMetaData(fromDate, toDate time.Time) (libraryMetaData *
 	}
 
 	if !response.Success {
-		return nil, errors.New(msg)
+		return nil, errors.WithMessage(statusError(resp, response.Status), msg)
 	}
 
 	return &response.Data, nil
 }
 
 func (a *Arlo) GetLibrary(fromDate, toDate time.Time) (library *Library, err error) {
+	return a.GetLibraryContext(context.Background(), fromDate, toDate)
+}
+
+// GetLibraryContext is like GetLibrary, but uses ctx for the request.
+func (a *Arlo) GetLibraryContext(ctx context.Context, fromDate, toDate time.Time) (library *Library, err error) {
 	msg := "failed to get library"
 
 	body := map[string]string{"dateFrom": fromDate.Format("20060102"), "dateTo": toDate.Format("20060102")}
-	resp, err := a.post(RecordingsUri, "", body, nil)
+	resp, err := a.post(ctx, RecordingsUri, "", body, nil)
 	if err != nil {
 		return nil, errors.WithMessage(err, msg)
 	}
@@ -91,7 +102,7 @@ func (a *Arlo) GetLibrary(fromDate, toDate time.Time) (library *Library, err err
 	}
 
 	if !response.Success {
-		return nil, errors.New(msg)
+		return nil, errors.WithMessage(statusError(resp, response.Status), msg)
 	}
 
 	return &response.Data, nil
@@ -105,8 +116,13 @@ func (a *Arlo) GetLibrary(fromDate, toDate time.Time) (library *Library, err err
  NOTE: {"data": [{"createdDate": r.CreatedDate, "utcCreatedDate": r.UtcCreatedDate, "deviceId": r.DeviceId}]} is all that's really required.
 */
 func (a *Arlo) DeleteRecording(r *Recording) error {
+	return a.DeleteRecordingContext(context.Background(), r)
+}
+
+// DeleteRecordingContext is like DeleteRecording, but uses ctx for the request.
+func (a *Arlo) DeleteRecordingContext(ctx context.Context, r *Recording) error {
 	body := map[string]Library{"data": {*r}}
-	resp, err := a.post(RecycleUri, "", body, nil)
+	resp, err := a.post(ctx, RecycleUri, "", body, nil)
 	return checkRequest(resp, err, "failed to delete recording")
 }
 
@@ -118,31 +134,51 @@ func (a *Arlo) DeleteRecording(r *Recording) error {
  NOTE: {"data": [{"createdDate": r.CreatedDate, "utcCreatedDate": r.UtcCreatedDate, "deviceId": r.DeviceId}]} is all that's really required.
 */
 func (a *Arlo) BatchDeleteRecordings(l *Library) error {
+	return a.BatchDeleteRecordingsContext(context.Background(), l)
+}
+
+// BatchDeleteRecordingsContext is like BatchDeleteRecordings, but uses ctx for the request.
+func (a *Arlo) BatchDeleteRecordingsContext(ctx context.Context, l *Library) error {
 	body := map[string]Library{"data": *l}
-	resp, err := a.post(RecycleUri, "", body, nil)
+	resp, err := a.post(ctx, RecycleUri, "", body, nil)
 	return checkRequest(resp, err, "failed to delete recordings")
 }
 
 // SendAnalyticFeedback is only really used by the GUI. It is a response to a prompt asking you whether an object which
 // was tagged by it's AI in your recording was tagged correctly.
 func (a *Arlo) SendAnalyticFeedback(r *Recording) error {
+	return a.SendAnalyticFeedbackContext(context.Background(), r)
+}
+
+// SendAnalyticFeedbackContext is like SendAnalyticFeedback, but uses ctx for the request.
+func (a *Arlo) SendAnalyticFeedbackContext(ctx context.Context, r *Recording) error {
 	category := "Person" // Other
 	body := map[string]map[string]interface{}{"data": {"utcCreatedDate": r.UtcCreatedDate, "category": category, "createdDate": r.CreatedDate}}
-	resp, err := a.put(AnalyticFeedbackUri, "", body, nil)
+	resp, err := a.put(ctx, AnalyticFeedbackUri, "", body, nil)
 	return checkRequest(resp, err, "failed to send analytic feedback about recording")
 }
 
 // GetActiveAutomationDefinitions gets the mode metadata (this API replaces the older GetModes(), which still works).
 func (a *Arlo) GetActiveAutomationDefinitions() error {
-	resp, err := a.get(ActiveAutomationUri, "", nil)
+	return a.GetActiveAutomationDefinitionsContext(context.Background())
+}
+
+// GetActiveAutomationDefinitionsContext is like GetActiveAutomationDefinitions, but uses ctx for the request.
+func (a *Arlo) GetActiveAutomationDefinitionsContext(ctx context.Context) error {
+	resp, err := a.get(ctx, ActiveAutomationUri, "", nil)
 	return checkRequest(resp, err, "failed to get active automation definitions")
 }
 
 /*
 func (a *Arlo) SetActiveAutomationMode() error {
+	return a.SetActiveAutomationModeContext(context.Background())
+}
+
+// SetActiveAutomationModeContext is like SetActiveAutomationMode, but uses ctx for the request.
+func (a *Arlo) SetActiveAutomationModeContext(ctx context.Context) error {
 
 	body := struct{}{} //map[string]map[string]interface{}{"data": {"utcCreatedDate": r.UtcCreatedDate, "category": category, "createdDate": r.CreatedDate}}
-	resp, err := a.put(AnalyticFeedbackUri, "", body, nil)
+	resp, err := a.put(ctx, AnalyticFeedbackUri, "", body, nil)
 	return checkRequest(resp, err, "failed to send analytic feedback about recording")
 }
 */
@@ -16,4 +16,237 @@
 
 package arlo
 
-// TODO: Add support for "lights" type of devices.
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Light flash patterns, for SetFlash.
+const (
+	LightFlashOff  = "off"
+	LightFlashSlow = "slow"
+	LightFlashFast = "fast"
+)
+
+// A Light is a Device of type "lights".
+// Lights are controlled through the event stream of their basestation, which is usually an Arlo Bridge.
+type Light Device
+
+// Lights is a slice of Light objects.
+type Lights []Light
+
+// Find returns a light with the device id passed in.
+func (ls *Lights) Find(deviceId string) *Light {
+	for i := range *ls {
+		if (*ls)[i].DeviceId == deviceId {
+			return &(*ls)[i]
+		}
+	}
+
+	return nil
+}
+
+// LightCollection is the lights of the account. It's a view of the devices, like DeviceCollection.
+type LightCollection struct {
+	view
+}
+
+var lightKinds = []DeviceKind{KindLight}
+
+// All returns the lights, in the order the Arlo API returned them.
+func (ls LightCollection) All() []*Light {
+	return lights(ls.list(nil))
+}
+
+// Range calls fn for each light, in order, until it returns false. See DeviceCollection.Range.
+func (ls LightCollection) Range(fn func(l *Light) bool) {
+	for _, l := range ls.All() {
+		if !fn(l) {
+			return
+		}
+	}
+}
+
+// Find returns the light with the device id, or nil if there isn't one.
+func (ls LightCollection) Find(deviceId string) *Light {
+	l, _ := ls.findId(deviceId).(*Light)
+	return l
+}
+
+// FindByName returns the first light with the name, or nil if there isn't one.
+func (ls LightCollection) FindByName(name string) *Light {
+	l, _ := ls.find(byName(name)).(*Light)
+	return l
+}
+
+// FindByUniqueId returns the light with the unique id, or nil if there isn't one.
+func (ls LightCollection) FindByUniqueId(uniqueId string) *Light {
+	l, _ := ls.find(byUniqueId(uniqueId)).(*Light)
+	return l
+}
+
+// FindByModel returns the lights of the model.
+func (ls LightCollection) FindByModel(modelId string) []*Light {
+	return lights(ls.list(byModel(modelId)))
+}
+
+func lights(vs []interface{}) []*Light {
+	var lights []*Light
+	for _, l := range vs {
+		lights = append(lights, l.(*Light))
+	}
+	return lights
+}
+
+// set sends the properties to the light, through the event stream of its basestation.
+func (l *Light) set(ctx context.Context, properties LightProperties, msg string) (*EventStreamResponse, error) {
+	payload := EventStreamPayload{
+		Action:          "set",
+		Resource:        fmt.Sprintf("lights/%s", l.DeviceId),
+		PublishResponse: true,
+		Properties:      properties,
+		From:            fmt.Sprintf("%s_%s", l.UserId, TransIdPrefix),
+		To:              l.ParentId,
+	}
+
+	return (*Device)(l).request(ctx, l.ParentId, payload, msg)
+}
+
+// GetState returns the current state of the light.
+func (l *Light) GetState() (response *EventStreamResponse, err error) {
+	return l.GetStateContext(context.Background())
+}
+
+// GetStateContext is like GetState, but uses ctx for the request.
+func (l *Light) GetStateContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return (*Device)(l).getState(ctx, l.ParentId, fmt.Sprintf("lights/%s", l.DeviceId), "failed to get light state")
+}
+
+// On turns the light on.
+func (l *Light) On() (response *EventStreamResponse, err error) {
+	return l.OnContext(context.Background())
+}
+
+// OnContext is like On, but uses ctx for the request.
+func (l *Light) OnContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return l.set(ctx, LightProperties{LampState: "on"}, "failed to turn light on")
+}
+
+// Off turns the light off.
+func (l *Light) Off() (response *EventStreamResponse, err error) {
+	return l.OffContext(context.Background())
+}
+
+// OffContext is like Off, but uses ctx for the request.
+func (l *Light) OffContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return l.set(ctx, LightProperties{LampState: "off"}, "failed to turn light off")
+}
+
+// SetBrightness sets the brightness of the light, as a percentage from 1 to 100.
+func (l *Light) SetBrightness(brightness int) (response *EventStreamResponse, err error) {
+	return l.SetBrightnessContext(context.Background(), brightness)
+}
+
+// SetBrightnessContext is like SetBrightness, but uses ctx for the request.
+func (l *Light) SetBrightnessContext(ctx context.Context, brightness int) (response *EventStreamResponse, err error) {
+	// Sanity check; if the value is above or below the allowed limits, set it to its limit.
+	if brightness < 1 {
+		brightness = 1
+	} else if brightness > 100 {
+		brightness = 100
+	}
+
+	return l.set(ctx, LightProperties{Brightness: brightness}, "failed to set light brightness")
+}
+
+// SetColorTemperature makes the light white, with the color temperature in kelvin, from 2700 (warm) to 6500 (cool).
+func (l *Light) SetColorTemperature(kelvin int) (response *EventStreamResponse, err error) {
+	return l.SetColorTemperatureContext(context.Background(), kelvin)
+}
+
+// SetColorTemperatureContext is like SetColorTemperature, but uses ctx for the request.
+func (l *Light) SetColorTemperatureContext(ctx context.Context, kelvin int) (response *EventStreamResponse, err error) {
+	if kelvin < 2700 {
+		kelvin = 2700
+	} else if kelvin > 6500 {
+		kelvin = 6500
+	}
+
+	properties := LightProperties{LightMode: &LightModeProperties{Mode: "white", Temperature: kelvin}}
+	return l.set(ctx, properties, "failed to set light color temperature")
+}
+
+// SetColor sets the light color to the RGB value specified by the three parameters, which have valid values from 0-255.
+func (l *Light) SetColor(red, green, blue int) (response *EventStreamResponse, err error) {
+	return l.SetColorContext(context.Background(), red, green, blue)
+}
+
+// SetColorContext is like SetColor, but uses ctx for the request.
+func (l *Light) SetColorContext(ctx context.Context, red, green, blue int) (response *EventStreamResponse, err error) {
+	clamp := func(v int) int {
+		if v < 0 {
+			return 0
+		} else if v > 255 {
+			return 255
+		}
+		return v
+	}
+
+	rgb := NightLightRGBProperties{Red: clamp(red), Green: clamp(green), Blue: clamp(blue)}
+	properties := LightProperties{LightMode: &LightModeProperties{Mode: "rgb", RGB: &rgb}}
+	return l.set(ctx, properties, "failed to set light color")
+}
+
+// SetDuration sets how long, in seconds, the light stays on after it's turned on by motion or by an automation rule.
+func (l *Light) SetDuration(seconds int) (response *EventStreamResponse, err error) {
+	return l.SetDurationContext(context.Background(), seconds)
+}
+
+// SetDurationContext is like SetDuration, but uses ctx for the request.
+func (l *Light) SetDurationContext(ctx context.Context, seconds int) (response *EventStreamResponse, err error) {
+	msg := "failed to set light duration"
+
+	if seconds < 1 {
+		return nil, errors.WithMessage(errors.New("duration must be at least 1 second"), msg)
+	}
+
+	return l.set(ctx, LightProperties{Duration: seconds}, msg)
+}
+
+// SetMotionActivation configures the light to turn on when it detects motion.
+// sensitivity is from 1 to 100, and duration is how long, in seconds, the light stays on after the motion stops.
+func (l *Light) SetMotionActivation(enabled bool, sensitivity int, duration int) (response *EventStreamResponse, err error) {
+	return l.SetMotionActivationContext(context.Background(), enabled, sensitivity, duration)
+}
+
+// SetMotionActivationContext is like SetMotionActivation, but uses ctx for the request.
+func (l *Light) SetMotionActivationContext(ctx context.Context, enabled bool, sensitivity int, duration int) (response *EventStreamResponse, err error) {
+	if sensitivity < 1 {
+		sensitivity = 1
+	} else if sensitivity > 100 {
+		sensitivity = 100
+	}
+
+	properties := LightProperties{Motion: &LightMotionProperties{Enabled: enabled, Sensitivity: sensitivity, Duration: duration}}
+	return l.set(ctx, properties, "failed to set light motion activation")
+}
+
+// SetFlash makes the light flash with the pattern when it's on. Valid values are: LightFlashOff, LightFlashSlow or LightFlashFast.
+func (l *Light) SetFlash(pattern string) (response *EventStreamResponse, err error) {
+	return l.SetFlashContext(context.Background(), pattern)
+}
+
+// SetFlashContext is like SetFlash, but uses ctx for the request.
+func (l *Light) SetFlashContext(ctx context.Context, pattern string) (response *EventStreamResponse, err error) {
+	msg := "failed to set light flash pattern"
+
+	switch pattern {
+	case LightFlashOff, LightFlashSlow, LightFlashFast:
+	default:
+		return nil, errors.WithMessage(fmt.Errorf("invalid flash pattern (%s)", pattern), msg)
+	}
+
+	return l.set(ctx, LightProperties{Flash: &LightFlashProperties{Pattern: pattern}}, msg)
+}
@@ -0,0 +1,248 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/jeffreydwalter/arlo-go/internal/request"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout            = 30 * time.Second
+	defaultEventStreamTimeout = 10 * time.Second
+	defaultPingInterval       = 30 * time.Second
+)
+
+type options struct {
+	baseURL            string
+	authURL            string
+	httpClient         *http.Client
+	transport          http.RoundTripper
+	proxy              *url.URL
+	tlsConfig          *tls.Config
+	userAgent          string
+	timeout            time.Duration
+	eventStreamTimeout time.Duration
+	pingInterval       time.Duration
+}
+
+// An Option configures the Arlo object made by NewClient.
+type Option func(*options)
+
+// WithBaseURL sets the url of the Arlo API, which is BaseUrl by default.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithAuthURL sets the url of the Arlo authentication api, which is AuthBaseUrl by default.
+func WithAuthURL(authURL string) Option {
+	return func(o *options) {
+		o.authURL = authURL
+	}
+}
+
+// WithHTTPClient sets the http.Client that the requests are made with. A copy of it is used, so all of its settings are
+// kept, with a few exceptions: WithTransport, WithProxy and WithTLSConfig change its Transport, a Jar is added if it
+// doesn't have one, and WithTimeout is used if it doesn't have a Timeout. The event stream doesn't use the Timeout, since
+// it's a long lived connection.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithTransport sets the http.RoundTripper that the requests, and the event stream, are made with.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// WithProxy makes all of the requests go through the proxy at proxyURL.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(o *options) {
+		o.proxy = proxyURL
+	}
+}
+
+// WithTLSConfig sets the TLS configuration that the requests are made with.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *options) {
+		o.tlsConfig = config
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with the requests.
+func WithUserAgent(userAgent string) Option {
+	return func(o *options) {
+		o.userAgent = userAgent
+	}
+}
+
+// WithTimeout sets the timeout of each request to the Arlo API, which is 30 seconds by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithEventStreamTimeout sets how long to wait for the response to a message sent to a basestation, when the context
+// doesn't have a deadline. It's 10 seconds by default.
+func WithEventStreamTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.eventStreamTimeout = timeout
+	}
+}
+
+// WithPingInterval sets how often the basestations are pinged to keep the event stream alive, which is 30 seconds by default.
+func WithPingInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.pingInterval = interval
+	}
+}
+
+// NewClient returns an Arlo object that isn't logged in yet, configured with opts.
+// Log in with one of its Login, LoginWithTwoFactor, BeginLogin or ResumeSession methods.
+func NewClient(opts ...Option) (*Arlo, error) {
+	o := options{
+		baseURL:            BaseUrl,
+		authURL:            AuthBaseUrl,
+		timeout:            defaultTimeout,
+		eventStreamTimeout: defaultEventStreamTimeout,
+		pingInterval:       defaultPingInterval,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	msg := "failed to create arlo client"
+
+	transport, err := o.roundTripper()
+	if err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	// Add important headers.
+	baseHeaders := make(http.Header)
+	baseHeaders.Add("DNT", "1")
+	baseHeaders.Add("schemaVersion", "1")
+	baseHeaders.Add("Host", "my.arlo.com")
+	baseHeaders.Add("Referer", "https://my.arlo.com/")
+
+	c, err := request.NewClient(o.baseURL, baseHeaders)
+	if err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	authHeaders := make(http.Header)
+	authHeaders.Add("Auth-Version", "2")
+	authHeaders.Add("Origin", "https://my.arlo.com")
+	authHeaders.Add("Referer", "https://my.arlo.com/")
+
+	ac, err := request.NewClient(o.authURL, authHeaders)
+	if err != nil {
+		return nil, errors.WithMessage(err, msg)
+	}
+
+	jar := c.HttpClient.Jar
+	for _, rc := range []*request.Client{c, ac} {
+		var hc http.Client
+		if o.httpClient != nil {
+			hc = *o.httpClient
+		}
+		hc.Transport = transport
+		// Share the cookies, so a trusted device is remembered by both hosts.
+		if hc.Jar == nil {
+			hc.Jar = jar
+		}
+		if hc.Timeout == 0 {
+			hc.Timeout = o.timeout
+		}
+		rc.HttpClient = &hc
+		if o.userAgent != "" {
+			rc.AddHeader("User-Agent", o.userAgent)
+		}
+	}
+
+	arlo := &Arlo{
+		client:              c,
+		authClient:          ac,
+		eventStreamTimeout:  o.eventStreamTimeout,
+		pingInterval:        o.pingInterval,
+		userAgent:           o.userAgent,
+		listeners:           newListeners(),
+		deviceListeners:     newDeviceListeners(),
+		states:              newStateStore(),
+		streaming:           make(map[string]bool),
+		subscribed:          make(map[string]bool),
+		capabilitiesCache:   make(map[string]*Capabilities),
+		capabilitiesFetches: make(map[string]*capabilitiesFetch),
+	}
+	c.Reauthenticate = arlo.reauthenticate
+
+	arlo.devices = newCollection()
+	arlo.Devices = DeviceCollection{view{arlo.devices, deviceKinds}}
+	arlo.Basestations = BasestationCollection{view{arlo.devices, basestationKinds}}
+	arlo.ArloQs = ArloQCollection{view{arlo.devices, arloQKinds}}
+	arlo.Bridges = BridgeCollection{view{arlo.devices, bridgeKinds}}
+	arlo.Cameras = CameraCollection{view{arlo.devices, cameraKinds}}
+	arlo.Doorbells = DoorbellCollection{view{arlo.devices, doorbellKinds}}
+	arlo.Chimes = ChimeCollection{view{arlo.devices, chimeKinds}}
+	arlo.Lights = LightCollection{view{arlo.devices, lightKinds}}
+	arlo.Sirens = SirenCollection{view{arlo.devices, sirenKinds}}
+
+	return arlo, nil
+}
+
+// roundTripper returns the transport for the requests, with the proxy and TLS configuration applied to it.
+func (o options) roundTripper() (http.RoundTripper, error) {
+	transport := o.transport
+	if transport == nil && o.httpClient != nil {
+		transport = o.httpClient.Transport
+	}
+
+	if o.proxy == nil && o.tlsConfig == nil {
+		return transport, nil
+	}
+
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	t, ok := transport.(*http.Transport)
+	if !ok {
+		return nil, errors.New("the proxy and TLS options can only be used with an *http.Transport")
+	}
+
+	t = t.Clone()
+	if o.proxy != nil {
+		t.Proxy = http.ProxyURL(o.proxy)
+	}
+	if o.tlsConfig != nil {
+		t.TLSClientConfig = o.tlsConfig
+	}
+
+	return t, nil
+}
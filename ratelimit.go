@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"github.com/jeffreydwalter/arlo-go/internal/request"
+)
+
+// RateLimit configures how often, and how many at once, requests are made. Requests over the limit wait their turn
+// (or until their context is done) instead of failing. The zero value doesn't limit anything.
+type RateLimit struct {
+	Rate        float64 // Requests per second, 0 means no limit.
+	Burst       int     // How many requests can be made back to back before Rate kicks in.
+	MaxInFlight int     // How many requests can be in flight at once, 0 means no limit.
+}
+
+func (l RateLimit) limiter() *request.Limiter {
+	if l.Rate <= 0 && l.MaxInFlight <= 0 {
+		return nil
+	}
+	return request.NewLimiter(l.Rate, l.Burst, l.MaxInFlight)
+}
+
+// SetRateLimit limits all of the requests made to the Arlo API, and the files downloaded with DownloadFile.
+func (a *Arlo) SetRateLimit(limit RateLimit) {
+	l := limit.limiter()
+	a.client.SetLimiter(l)
+	a.authClient.SetLimiter(l)
+}
+
+// SetNotifyRateLimit limits the commands sent to the basestations, separately for each basestation.
+// These requests are also subject to the limit set with SetRateLimit.
+func (a *Arlo) SetNotifyRateLimit(limit RateLimit) {
+	a.notifyMutex.Lock()
+	defer a.notifyMutex.Unlock()
+	a.notifyLimit = limit
+	a.notifyLimiters = make(map[string]*request.Limiter)
+}
+
+// notifyLimiter returns the limiter for the commands sent to the basestation with the given device id.
+// It has a mutex of its own, so the commands don't contend with everything else that's guarded by rwmutex.
+func (a *Arlo) notifyLimiter(deviceId string) *request.Limiter {
+	a.notifyMutex.Lock()
+	defer a.notifyMutex.Unlock()
+
+	if l, ok := a.notifyLimiters[deviceId]; ok {
+		return l
+	}
+
+	l := a.notifyLimit.limiter()
+	if l != nil {
+		a.notifyLimiters[deviceId] = l
+	}
+	return l
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// CredentialProvider returns the username and password to log in again with when the auth token expires.
+// Use it when the password shouldn't be kept in memory, or when the Arlo object was made with ResumeSession.
+type CredentialProvider func(ctx context.Context) (user string, pass string, err error)
+
+// SetCredentialProvider sets where the credentials come from when the Arlo object has to log in again.
+// Without one, the username and password given to Login are used.
+func (a *Arlo) SetCredentialProvider(provider CredentialProvider) {
+	a.rwmutex.Lock()
+	defer a.rwmutex.Unlock()
+	a.credentials = provider
+}
+
+// SetTwoFactorHandler sets the handler that supplies the second factor when the Arlo object has to log in again.
+// LoginWithTwoFactor sets it to the handler it was given.
+func (a *Arlo) SetTwoFactorHandler(handler TwoFactorHandler) {
+	a.rwmutex.Lock()
+	defer a.rwmutex.Unlock()
+	a.twoFactor = handler
+}
+
+// SetTokenStore sets a store that the session is saved to every time the Arlo object logs in again.
+func (a *Arlo) SetTokenStore(store TokenStore) {
+	a.rwmutex.Lock()
+	defer a.rwmutex.Unlock()
+	a.tokenStore = store
+}
+
+// OnReauthenticate calls handler every time the Arlo object tries to log in again because its auth token was rejected.
+// err is nil if it succeeded, in which case the request that was rejected is sent again.
+func (a *Arlo) OnReauthenticate(handler func(err error)) {
+	a.rwmutex.Lock()
+	defer a.rwmutex.Unlock()
+	a.onReauthenticate = handler
+}
+
+// reauthenticate logs in again, and points the event stream at the new token.
+// It's called by the request client when a request is rejected with 401 Unauthorized.
+func (a *Arlo) reauthenticate(ctx context.Context) error {
+	err := a.relogin(ctx)
+
+	a.rwmutex.RLock()
+	store := a.tokenStore
+	handler := a.onReauthenticate
+	a.rwmutex.RUnlock()
+
+	if err == nil && store != nil {
+		err = a.SaveSession(store)
+	}
+
+	if handler != nil {
+		handler(err)
+	}
+
+	return err
+}
+
+func (a *Arlo) relogin(ctx context.Context) error {
+	a.rwmutex.RLock()
+	credentials := a.credentials
+	handler := a.twoFactor
+	authApi := a.authApi
+	a.rwmutex.RUnlock()
+
+	if credentials != nil {
+		user, pass, err := credentials(ctx)
+		if err != nil {
+			return errors.WithMessage(err, "failed to get credentials")
+		}
+		a.setCredentials(user, pass)
+	}
+
+	if _, pass := a.userCredentials(); pass == "" {
+		return errors.New("no password to log in again with, use SetCredentialProvider()")
+	}
+
+	var account Account
+	if authApi {
+		auth, err := a.beginLogin(ctx)
+		if err != nil {
+			return err
+		}
+
+		if err := auth.secondFactor(ctx, handler); err != nil {
+			return err
+		}
+
+		if account, err = auth.finish(ctx); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if account, err = a.login(ctx); err != nil {
+			return err
+		}
+	}
+
+	a.authenticated(account)
+
+	// The event stream url has the token in it, so reconnect with the new one.
+	// Requests waiting on the event stream are sent again once it's back.
+	if es := a.stream(); es != nil {
+		es.setURL(a.streamURL())
+		es.reconnect()
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A DeviceEventType is what happened to the device of a DeviceEvent.
+type DeviceEventType string
+
+const (
+	DeviceAdded   DeviceEventType = "added"
+	DeviceRemoved DeviceEventType = "removed"
+	DeviceChanged DeviceEventType = "changed"
+)
+
+// The fields of a device that a DeviceChanged event is sent for, by their names in DeviceEvent.Changes. The other fields,
+// like the presigned urls of the last image, change all the time and are only updated.
+const (
+	ChangeFirmwareVersion = "firmwareVersion"
+	ChangeDeviceName      = "deviceName"
+	ChangeParentId        = "parentId"
+	ChangeConnectivity    = "connectivity"
+)
+
+// A DeviceEvent is a device that was added to or removed from the account, or that changed, since the devices were last
+// fetched by GetDevices or RefreshDevices.
+type DeviceEvent struct {
+	Type     DeviceEventType // DeviceAdded, DeviceRemoved or DeviceChanged.
+	Device   Device          // The device, as it was before it was removed for DeviceRemoved.
+	Previous Device          // The device before it changed, for DeviceChanged.
+	Changes  []string        // The fields that changed, for DeviceChanged.
+}
+
+// diff returns the fields of the device that changed.
+func diff(previous, d Device) []string {
+	var changes []string
+	if previous.FirmwareVersion != d.FirmwareVersion {
+		changes = append(changes, ChangeFirmwareVersion)
+	}
+	if previous.DeviceName != d.DeviceName {
+		changes = append(changes, ChangeDeviceName)
+	}
+	if previous.ParentId != d.ParentId {
+		changes = append(changes, ChangeParentId)
+	}
+	if previous.Connectivity != d.Connectivity {
+		changes = append(changes, ChangeConnectivity)
+	}
+	return changes
+}
+
+// isHub reports whether the device connects to the event stream.
+func isHub(d Device) bool {
+	switch d.Kind() {
+	case KindBasestation, KindArloQ, KindBridge:
+		return true
+	}
+	return false
+}
+
+// RefreshDevices fetches the devices again, and updates the devices cached in the arlo object with them. Unlike
+// GetDevices, it leaves the event stream alone: only the basestations that were added are subscribed to it, and the ones
+// that were removed are disconnected from it.
+//
+// It returns the devices that were added, removed or changed, which are also sent to the listeners of WatchDevices.
+func (a *Arlo) RefreshDevices() (events []DeviceEvent, err error) {
+	return a.RefreshDevicesContext(context.Background())
+}
+
+// RefreshDevicesContext is like RefreshDevices, but uses ctx for the request and for subscribing the new basestations.
+func (a *Arlo) RefreshDevicesContext(ctx context.Context) (events []DeviceEvent, err error) {
+	devices, err := a.fetchDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	events = a.devices.replace(devices)
+	a.states.seed(devices)
+	a.deviceListeners.dispatch(events)
+
+	for _, event := range events {
+		if event.Type == DeviceRemoved && isHub(event.Device) {
+			a.unsubscribe(event.Device.DeviceId)
+		}
+	}
+
+	// A basestation that failed to subscribe before gets another try.
+	for _, b := range a.hubs() {
+		if a.isSubscribed(b.DeviceId) {
+			continue
+		}
+		if err := b.SubscribeContext(ctx); err != nil {
+			return events, errors.WithMessage(err, "failed to refresh devices")
+		}
+	}
+
+	return events, nil
+}
+
+// A DeviceListener receives the devices that are added, removed or changed on the Events channel.
+// Events are buffered; if the buffer is full because the channel isn't being read, new events are dropped.
+type DeviceListener struct {
+	Events <-chan DeviceEvent
+
+	events    chan DeviceEvent
+	listeners *deviceListeners
+}
+
+// Unsubscribe stops the delivery of events and closes the Events channel.
+func (l *DeviceListener) Unsubscribe() {
+	l.listeners.remove(l)
+}
+
+type deviceListeners struct {
+	rwmutex   sync.RWMutex
+	listeners map[*DeviceListener]bool
+}
+
+func newDeviceListeners() *deviceListeners {
+	return &deviceListeners{listeners: make(map[*DeviceListener]bool)}
+}
+
+func (ls *deviceListeners) add() *DeviceListener {
+	events := make(chan DeviceEvent, eventBufferSize)
+	l := &DeviceListener{Events: events, events: events, listeners: ls}
+
+	ls.rwmutex.Lock()
+	ls.listeners[l] = true
+	ls.rwmutex.Unlock()
+
+	return l
+}
+
+func (ls *deviceListeners) remove(l *DeviceListener) {
+	ls.rwmutex.Lock()
+	defer ls.rwmutex.Unlock()
+	if _, ok := ls.listeners[l]; ok {
+		close(l.events)
+		delete(ls.listeners, l)
+	}
+}
+
+func (ls *deviceListeners) dispatch(events []DeviceEvent) {
+	ls.rwmutex.RLock()
+	defer ls.rwmutex.RUnlock()
+
+	for l := range ls.listeners {
+		for _, event := range events {
+			select {
+			case l.events <- event:
+			default:
+			}
+		}
+	}
+}
+
+// WatchDevices returns a listener that receives the devices that are added, removed or changed when the devices are
+// fetched by GetDevices or RefreshDevices. Call Unsubscribe() on the listener when you're done.
+func (a *Arlo) WatchDevices() *DeviceListener {
+	return a.deviceListeners.add()
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import "sync"
+
+// A DeviceKind is what a device is, which decides the type it's wrapped in, and the collection of the Arlo object it's
+// cached in by GetDevices.
+type DeviceKind string
+
+const (
+	KindUnknown     DeviceKind = ""
+	KindArloQ       DeviceKind = "arloq"
+	KindBasestation DeviceKind = "basestation"
+	KindBridge      DeviceKind = "bridge"
+	KindCamera      DeviceKind = "camera"
+	KindChime       DeviceKind = "chime"
+	KindDoorbell    DeviceKind = "doorbell"
+	KindLight       DeviceKind = "light"
+	KindSiren       DeviceKind = "siren"
+)
+
+// The registry of device kinds. A device's modelId is looked up first, since some models report a generic deviceType,
+// and then its deviceType.
+var (
+	registryMutex sync.RWMutex
+
+	kindsByType = map[string]DeviceKind{
+		DeviceTypeArloQ:       KindArloQ,
+		DeviceTypeArloQs:      KindArloQ,
+		DeviceTypeArloBridge:  KindBridge,
+		DeviceTypeBasestation: KindBasestation,
+		DeviceTypeCamera:      KindCamera,
+		DeviceTypeChime:       KindChime,
+		DeviceTypeDoorbell:    KindDoorbell,
+		DeviceTypeLights:      KindLight,
+		DeviceTypeSiren:       KindSiren,
+	}
+
+	kindsByModel = map[string]DeviceKind{
+		"ABB1000":  KindBridge,
+		"VMC3040":  KindArloQ,
+		"VMC3040S": KindArloQ,
+		"AAD1001":  KindDoorbell,
+		"AVD1001":  KindDoorbell,
+		"AC1001":   KindChime,
+	}
+)
+
+// RegisterDeviceType sets the kind of the devices with the deviceType, for device types this package doesn't know about.
+func RegisterDeviceType(deviceType string, kind DeviceKind) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	kindsByType[deviceType] = kind
+}
+
+// RegisterModel sets the kind of the devices with the modelId. It takes precedence over the kind of their deviceType.
+func RegisterModel(modelId string, kind DeviceKind) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	kindsByModel[modelId] = kind
+}
+
+// Kind returns the kind of the device, from its modelId or deviceType. A device that isn't registered is a basestation
+// if it's its own parent, and KindUnknown otherwise.
+func (d Device) Kind() DeviceKind {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	if kind, ok := kindsByModel[d.ModelId]; ok {
+		return kind
+	}
+	if kind, ok := kindsByType[d.DeviceType]; ok {
+		return kind
+	}
+	if d.DeviceId != "" && d.DeviceId == d.ParentId {
+		return KindBasestation
+	}
+	return KindUnknown
+}
+
+// Wrap returns the device as the concrete type of its kind: *ArloQ, *Basestation, *Bridge, *Camera, *Chime, *Doorbell,
+// *Light or *Siren. A device of an unknown kind is returned as a *Device.
+func (d Device) Wrap() interface{} {
+	switch d.Kind() {
+	case KindArloQ:
+		return newArloQ(d)
+	case KindBasestation:
+		return &Basestation{Device: d}
+	case KindBridge:
+		return &Bridge{Basestation{Device: d}}
+	case KindCamera:
+		c := Camera(d)
+		return &c
+	case KindChime:
+		c := Chime(d)
+		return &c
+	case KindDoorbell:
+		db := Doorbell(d)
+		return &db
+	case KindLight:
+		l := Light(d)
+		return &l
+	case KindSiren:
+		s := Siren(d)
+		return &s
+	default:
+		return &d
+	}
+}
@@ -16,6 +16,8 @@
 
 package arlo
 
+import "encoding/json"
+
 // URL is part of the Status message fragment returned by most calls to the Arlo API.
 // URL is only populated when Success is false.
 type Data struct {
@@ -30,6 +32,40 @@ type Status struct {
 	Success bool `json:"success"`
 }
 
+// AuthMeta is the message fragment returned from all http calls to the Arlo authentication api.
+type AuthMeta struct {
+	Meta struct {
+		Code    int    `json:"code"`
+		Error   int    `json:"error,omitempty"`
+		Message string `json:"message,omitempty"`
+	} `json:"meta"`
+}
+
+// Success returns true if the authentication api call succeeded.
+func (m AuthMeta) Success() bool {
+	return m.Meta.Code == 200
+}
+
+// AuthResponse is an intermediate struct used when parsing data from the authentication api.
+type AuthResponse struct {
+	Data AuthToken
+	AuthMeta
+}
+
+type FactorsResponse struct {
+	Data struct {
+		Items []Factor `json:"items"`
+	}
+	AuthMeta
+}
+
+type StartAuthResponse struct {
+	Data struct {
+		FactorAuthCode string `json:"factorAuthCode"`
+	}
+	AuthMeta
+}
+
 // LoginResponse is an intermediate struct used when parsing data from the Login() call.
 type LoginResponse struct {
 	Data Account
@@ -84,5 +120,10 @@ type RecordingResponse struct {
 
 type EventStreamResponse struct {
 	EventStreamPayload
-	Status string `json:"status,omitempty"`
+	DeviceId            string `json:"deviceId,omitempty"`
+	PresignedContentUrl string `json:"presignedContentUrl,omitempty"`
+	Status              string `json:"status,omitempty"`
+
+	// RawProperties are the properties as they were received from the event stream. Use Decode to decode them.
+	RawProperties json.RawMessage `json:"-"`
 }
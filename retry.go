@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"github.com/jeffreydwalter/arlo-go/internal/request"
+)
+
+// RetryPolicy decides whether, and when, a failed request to the Arlo API is sent again.
+// Overrides are keyed by uri prefix, e.g. "/users/devices/notify/" for the commands sent to basestations.
+type RetryPolicy = request.RetryPolicy
+
+// RequestStats are counters of the requests made to the Arlo API.
+type RequestStats = request.Stats
+
+// DefaultRetryPolicy is the RetryPolicy used unless SetRetryPolicy is called: up to 3 attempts, with a backoff of 0.5s to 10s.
+var DefaultRetryPolicy = request.DefaultRetryPolicy
+
+// SetRetryPolicy sets the retry policy for every request made to the Arlo API.
+func (a *Arlo) SetRetryPolicy(policy RetryPolicy) {
+	a.client.SetRetryPolicy(policy)
+	a.authClient.SetRetryPolicy(policy)
+}
+
+// RequestStats returns the counters of the requests made to the Arlo API so far.
+func (a *Arlo) RequestStats() RequestStats {
+	return a.client.Stats().Add(a.authClient.Stats())
+}
@@ -0,0 +1,303 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jeffreydwalter/arlo-go/internal/request"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoSession is returned by a TokenStore when it doesn't have a saved session.
+var ErrNoSession = errors.New("no saved session")
+
+// SavedSession is everything needed to resume a login without the password (and without two-factor authentication).
+// Treat it like a password, since anyone who has it can use the account until the token expires.
+type SavedSession struct {
+	Token  string `json:"token"`
+	UserId string `json:"userId"`
+	Email  string `json:"email"`
+	// Cookies are the cookies from the cookie jar, keyed by the url they belong to.
+	// They include the cookie that marks this client as a trusted device.
+	Cookies map[string][]*http.Cookie `json:"cookies,omitempty"`
+	// Expires is when the token expires. It's the zero time if the expiry isn't known.
+	Expires time.Time `json:"expires,omitempty"`
+	// AuthApi is true if the login went through the authentication api, which is then used to log in again when the
+	// token has to be renewed.
+	AuthApi bool `json:"authApi,omitempty"`
+}
+
+// Expired returns true if the token is known to have expired.
+func (s *SavedSession) Expired() bool {
+	return !s.Expires.IsZero() && time.Now().After(s.Expires)
+}
+
+// TokenStore saves and loads a SavedSession, so a session can outlive the process that logged in.
+type TokenStore interface {
+	// Load returns the saved session, or ErrNoSession if there isn't one.
+	Load() (*SavedSession, error)
+	Save(session *SavedSession) error
+	Clear() error
+}
+
+// ExportSession returns the current session, so it can be saved and resumed later with ResumeSession.
+func (a *Arlo) ExportSession() *SavedSession {
+	cookies := make(map[string][]*http.Cookie)
+	for _, u := range []*url.URL{a.client.BaseURL, a.authClient.BaseURL} {
+		if c := a.client.HttpClient.Jar.Cookies(u); len(c) > 0 {
+			cookies[u.String()] = c
+		}
+	}
+
+	a.rwmutex.RLock()
+	defer a.rwmutex.RUnlock()
+	return &SavedSession{
+		Token:   a.Account.Token,
+		UserId:  a.Account.UserId,
+		Email:   a.Account.Email,
+		Cookies: cookies,
+		Expires: a.expires,
+		AuthApi: a.authApi,
+	}
+}
+
+// SaveSession exports the current session to store.
+func (a *Arlo) SaveSession(store TokenStore) error {
+	if err := store.Save(a.ExportSession()); err != nil {
+		return errors.WithMessage(err, "failed to save session")
+	}
+	return nil
+}
+
+// ResumeSession logs in with a session from ExportSession instead of a username and password.
+// The session is validated with CheckSession, and then the devices are fetched like Login does.
+func ResumeSession(session *SavedSession) (*Arlo, error) {
+	return ResumeSessionContext(context.Background(), session)
+}
+
+// ResumeSessionContext is like ResumeSession, but uses ctx for the requests.
+func ResumeSessionContext(ctx context.Context, session *SavedSession) (*Arlo, error) {
+	arlo, err := NewClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := arlo.ResumeSessionContext(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return arlo, nil
+}
+
+// ResumeSession logs in an Arlo object made with NewClient, like the ResumeSession function does.
+func (a *Arlo) ResumeSession(session *SavedSession) error {
+	return a.ResumeSessionContext(context.Background(), session)
+}
+
+// ResumeSessionContext is like ResumeSession, but uses ctx for the requests.
+func (a *Arlo) ResumeSessionContext(ctx context.Context, session *SavedSession) error {
+	msg := "failed to resume session"
+
+	if session.Token == "" {
+		return errors.WithMessage(errors.New("session has no token"), msg)
+	}
+
+	if session.Expired() {
+		return errors.WithMessage(errors.New("session expired"), msg)
+	}
+
+	a.setCredentials(session.Email, "")
+	a.rwmutex.Lock()
+	a.expires = session.Expires
+	// If the token has to be renewed, log in again the same way as the session was made.
+	a.authApi = session.AuthApi
+	a.rwmutex.Unlock()
+
+	for rawurl, cookies := range session.Cookies {
+		u, err := url.Parse(rawurl)
+		if err != nil {
+			return errors.WithMessage(err, msg)
+		}
+		a.client.HttpClient.Jar.SetCookies(u, cookies)
+	}
+
+	a.client.AddHeader("Authorization", session.Token)
+
+	// A rejected token is an error here, rather than a reason to log in again.
+	s, err := a.CheckSessionContext(request.WithoutReauthentication(ctx))
+	if err != nil {
+		return errors.WithMessage(err, msg)
+	}
+
+	account := s.Account
+	if account.Token == "" {
+		account.Token = session.Token
+	}
+
+	if err := a.loggedIn(ctx, account); err != nil {
+		return errors.WithMessage(err, msg)
+	}
+
+	return nil
+}
+
+// Resume logs in with the session saved in store. See ResumeSession.
+func Resume(store TokenStore) (*Arlo, error) {
+	return ResumeContext(context.Background(), store)
+}
+
+// ResumeContext is like Resume, but uses ctx for the requests.
+func ResumeContext(ctx context.Context, store TokenStore) (*Arlo, error) {
+	session, err := store.Load()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to resume session")
+	}
+	return ResumeSessionContext(ctx, session)
+}
+
+// Resume logs in an Arlo object made with NewClient, with the session saved in store.
+func (a *Arlo) Resume(store TokenStore) error {
+	return a.ResumeContext(context.Background(), store)
+}
+
+// ResumeContext is like Resume, but uses ctx for the requests.
+func (a *Arlo) ResumeContext(ctx context.Context, store TokenStore) error {
+	session, err := store.Load()
+	if err != nil {
+		return errors.WithMessage(err, "failed to resume session")
+	}
+	return a.ResumeSessionContext(ctx, session)
+}
+
+// FileTokenStore is a TokenStore that saves the session as JSON in a file that only the current user can read.
+type FileTokenStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+func (s *FileTokenStore) Load() (*SavedSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read session file")
+	}
+
+	var session SavedSession
+	if err := json.Unmarshal(b, &session); err != nil {
+		return nil, errors.Wrap(err, "failed to parse session file")
+	}
+
+	return &session, nil
+}
+
+// Save writes the session to a temporary file first, so a crash can't leave a partially written session behind.
+func (s *FileTokenStore) Save(session *SavedSession) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	b, err := json.Marshal(session)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode session")
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to write session file")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to write session file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to write session file")
+	}
+
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return errors.Wrap(err, "failed to write session file")
+	}
+
+	return nil
+}
+
+func (s *FileTokenStore) Clear() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "failed to remove session file")
+	}
+	return nil
+}
+
+// MemoryTokenStore is a TokenStore that keeps the session in memory, which is mostly useful for tests.
+type MemoryTokenStore struct {
+	session *SavedSession
+	mutex   sync.Mutex
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (s *MemoryTokenStore) Load() (*SavedSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.session == nil {
+		return nil, ErrNoSession
+	}
+	session := *s.session
+	return &session, nil
+}
+
+func (s *MemoryTokenStore) Save(session *SavedSession) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	saved := *session
+	s.session = &saved
+	return nil
+}
+
+func (s *MemoryTokenStore) Clear() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.session = nil
+	return nil
+}
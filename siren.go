@@ -0,0 +1,237 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Siren patterns, for SirenOptions.
+const (
+	SirenPatternAlarm = "alarm"
+)
+
+// The limits of the siren options. Values outside of them are set to the nearest limit.
+const (
+	SirenMinDuration = 1
+	SirenMaxDuration = 300 // seconds
+	SirenMinVolume   = 1
+	SirenMaxVolume   = 8
+)
+
+// SirenOptions configures how a siren sounds when it's turned on.
+type SirenOptions struct {
+	Duration int    // How long, in seconds, the siren sounds before it turns itself off.
+	Volume   int    // From 1 to 8.
+	Pattern  string // Only SirenPatternAlarm is supported.
+}
+
+// DefaultSirenOptions sounds the siren at full volume for 5 minutes.
+var DefaultSirenOptions = SirenOptions{
+	Duration: SirenMaxDuration,
+	Volume:   SirenMaxVolume,
+	Pattern:  SirenPatternAlarm,
+}
+
+// A Siren is a Device of type "siren", or the built-in siren of a basestation, which is returned by Basestation.Siren().
+// A standalone siren is controlled through the event stream of the basestation it's connected to.
+type Siren Device
+
+// Sirens is a slice of Siren objects.
+type Sirens []Siren
+
+// Find returns a siren with the device id passed in.
+func (ss *Sirens) Find(deviceId string) *Siren {
+	for i := range *ss {
+		if (*ss)[i].DeviceId == deviceId {
+			return &(*ss)[i]
+		}
+	}
+
+	return nil
+}
+
+// SirenCollection is the sirens of the account. It's a view of the devices, like DeviceCollection.
+type SirenCollection struct {
+	view
+}
+
+var sirenKinds = []DeviceKind{KindSiren}
+
+// All returns the sirens, in the order the Arlo API returned them.
+func (ss SirenCollection) All() []*Siren {
+	return sirens(ss.list(nil))
+}
+
+// Range calls fn for each siren, in order, until it returns false. See DeviceCollection.Range.
+func (ss SirenCollection) Range(fn func(s *Siren) bool) {
+	for _, s := range ss.All() {
+		if !fn(s) {
+			return
+		}
+	}
+}
+
+// Find returns the siren with the device id, or nil if there isn't one.
+func (ss SirenCollection) Find(deviceId string) *Siren {
+	s, _ := ss.findId(deviceId).(*Siren)
+	return s
+}
+
+// FindByName returns the first siren with the name, or nil if there isn't one.
+func (ss SirenCollection) FindByName(name string) *Siren {
+	s, _ := ss.find(byName(name)).(*Siren)
+	return s
+}
+
+// FindByUniqueId returns the siren with the unique id, or nil if there isn't one.
+func (ss SirenCollection) FindByUniqueId(uniqueId string) *Siren {
+	s, _ := ss.find(byUniqueId(uniqueId)).(*Siren)
+	return s
+}
+
+// FindByModel returns the sirens of the model.
+func (ss SirenCollection) FindByModel(modelId string) []*Siren {
+	return sirens(ss.list(byModel(modelId)))
+}
+
+func sirens(vs []interface{}) []*Siren {
+	var sirens []*Siren
+	for _, s := range vs {
+		sirens = append(sirens, s.(*Siren))
+	}
+	return sirens
+}
+
+// Siren returns the built-in siren of the basestation.
+func (b *Basestation) Siren() *Siren {
+	s := Siren(b.Device)
+	s.ParentId = b.DeviceId
+	return &s
+}
+
+// basestationId returns the device id of the basestation that the siren's messages go through.
+func (s *Siren) basestationId() string {
+	if s.ParentId == "" {
+		return s.DeviceId
+	}
+	return s.ParentId
+}
+
+// resource returns the event stream resource of the siren. The built-in siren of a basestation is just "siren".
+func (s *Siren) resource() string {
+	if s.basestationId() == s.DeviceId {
+		return "siren"
+	}
+	return fmt.Sprintf("sirens/%s", s.DeviceId)
+}
+
+// GetState returns the current state of the siren. The sirenState property is "on" while it's sounding.
+func (s *Siren) GetState() (response *EventStreamResponse, err error) {
+	return s.GetStateContext(context.Background())
+}
+
+// GetStateContext is like GetState, but uses ctx for the request.
+func (s *Siren) GetStateContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return (*Device)(s).getState(ctx, s.basestationId(), s.resource(), "failed to get siren state")
+}
+
+// On sounds the siren with the options. Use DefaultSirenOptions for the same behavior as the Arlo app.
+func (s *Siren) On(options SirenOptions) (response *EventStreamResponse, err error) {
+	return s.OnContext(context.Background(), options)
+}
+
+// OnContext is like On, but uses ctx for the request.
+func (s *Siren) OnContext(ctx context.Context, options SirenOptions) (response *EventStreamResponse, err error) {
+	msg := "failed to turn siren on"
+
+	if options.Pattern != SirenPatternAlarm {
+		return nil, errors.WithMessage(fmt.Errorf("invalid siren pattern (%s)", options.Pattern), msg)
+	}
+
+	// Sanity check; if the values are above or below the allowed limits, set them to their limit.
+	if options.Duration < SirenMinDuration {
+		options.Duration = SirenMinDuration
+	} else if options.Duration > SirenMaxDuration {
+		options.Duration = SirenMaxDuration
+	}
+	if options.Volume < SirenMinVolume {
+		options.Volume = SirenMinVolume
+	} else if options.Volume > SirenMaxVolume {
+		options.Volume = SirenMaxVolume
+	}
+
+	return s.set(ctx, "on", options, msg)
+}
+
+// Off silences the siren.
+func (s *Siren) Off() (response *EventStreamResponse, err error) {
+	return s.OffContext(context.Background())
+}
+
+// OffContext is like Off, but uses ctx for the request.
+func (s *Siren) OffContext(ctx context.Context) (response *EventStreamResponse, err error) {
+	return s.set(ctx, "off", DefaultSirenOptions, "failed to turn siren off")
+}
+
+func (s *Siren) set(ctx context.Context, state string, options SirenOptions, msg string) (*EventStreamResponse, error) {
+	// A standalone siren is nothing but a siren; a basestation might not have one built in.
+	if s.resource() == "siren" {
+		if err := (*Device)(s).require(ctx, FeatureSiren); err != nil {
+			return nil, errors.WithMessage(err, msg)
+		}
+	}
+
+	payload := EventStreamPayload{
+		Action:          "set",
+		Resource:        s.resource(),
+		PublishResponse: true,
+		Properties: SirenProperties{
+			SirenState: state,
+			Duration:   options.Duration,
+			Volume:     options.Volume,
+			Pattern:    options.Pattern,
+		},
+		From: fmt.Sprintf("%s_%s", s.UserId, TransIdPrefix),
+		To:   s.basestationId(),
+	}
+
+	return (*Device)(s).request(ctx, s.basestationId(), payload, msg)
+}
+
+// Events returns a listener for the changes to the state of the siren, including the ones that weren't made by this
+// client, like when the siren is activated by a mode or rule. Call Unsubscribe() on the listener when you're done.
+func (s *Siren) Events() *EventListener {
+	return s.arlo.listeners.add(EventFilter{Resource: s.resource(), Action: "is"}, s.basestationId())
+}
+
+// OnActivation calls handler with true when the siren starts sounding, and with false when it stops.
+// The handler is called from its own goroutine, one event at a time. Call Unsubscribe() on the returned listener to stop it.
+func (s *Siren) OnActivation(handler func(on bool)) *EventListener {
+	filter := EventFilter{Resource: s.resource(), Action: "is"}
+	return s.arlo.listeners.addFunc(filter, s.basestationId(), func(e *EventStreamResponse) {
+		switch stringProperty(e.Properties, "sirenState") {
+		case "on":
+			handler(true)
+		case "off":
+			handler(false)
+		}
+	})
+}
@@ -0,0 +1,317 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DeviceState is the latest known state of a device. It's seeded with the device data from GetDevices, and then
+// patched with the properties of the messages about the device that arrive on the event stream, e.g. its battery level,
+// signal strength, connection state and privacy mode, as well as the active mode of a basestation. The state of the
+// built-in siren of a basestation is in its "siren" property.
+type DeviceState struct {
+	DeviceId   string
+	Mode       string                 // The active mode of a basestation, if it's known.
+	Properties map[string]interface{} // Decoded JSON, so numbers are float64.
+	Updated    time.Time              // When the state last changed.
+}
+
+// Property returns the value of a top-level property.
+func (s DeviceState) Property(key string) (value interface{}, ok bool) {
+	value, ok = s.Properties[key]
+	return value, ok
+}
+
+// Decode decodes the properties into v, e.g. a *CameraState.
+func (s DeviceState) Decode(v interface{}) error {
+	b, err := json.Marshal(s.Properties)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode device state")
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errors.Wrap(err, "failed to decode device state")
+	}
+	return nil
+}
+
+func (s *DeviceState) copy() DeviceState {
+	c := *s
+	c.Properties, _ = copyValue(s.Properties).(map[string]interface{})
+	return c
+}
+
+// copyValue makes a deep copy of decoded JSON.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		c := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			c[k] = copyValue(value)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, value := range v {
+			c[i] = copyValue(value)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// A StateListener receives a copy of the state of a device every time it changes on the Changes channel.
+// Changes are buffered; if the buffer is full because the channel isn't being read, new changes are dropped.
+type StateListener struct {
+	Changes <-chan DeviceState
+
+	changes  chan DeviceState
+	deviceId string
+	store    *stateStore
+}
+
+// Unsubscribe stops the delivery of changes and closes the Changes channel.
+func (l *StateListener) Unsubscribe() {
+	l.store.remove(l)
+}
+
+type stateStore struct {
+	rwmutex   sync.RWMutex
+	states    map[string]*DeviceState
+	listeners map[*StateListener]bool
+}
+
+func newStateStore() *stateStore {
+	return &stateStore{
+		states:    make(map[string]*DeviceState),
+		listeners: make(map[*StateListener]bool),
+	}
+}
+
+// get returns a copy of the state of the device.
+func (s *stateStore) get(deviceId string) (DeviceState, bool) {
+	s.rwmutex.RLock()
+	defer s.rwmutex.RUnlock()
+	state, ok := s.states[deviceId]
+	if !ok {
+		return DeviceState{}, false
+	}
+	return state.copy(), true
+}
+
+// all returns a copy of the state of every device, by device id.
+func (s *stateStore) all() map[string]DeviceState {
+	s.rwmutex.RLock()
+	defer s.rwmutex.RUnlock()
+	states := make(map[string]DeviceState, len(s.states))
+	for id, state := range s.states {
+		states[id] = state.copy()
+	}
+	return states
+}
+
+// seed merges the device data into the state of each device, and forgets the devices that are gone. It's done under
+// one lock, so a message from the event stream is applied either before or after all of it.
+func (s *stateStore) seed(devices Devices) {
+	ids := make(map[string]bool, len(devices))
+	var changed []string
+
+	s.rwmutex.Lock()
+	for _, d := range devices {
+		ids[d.DeviceId] = true
+
+		var properties map[string]interface{}
+		if b, err := json.Marshal(d); err == nil && json.Unmarshal(b, &properties) == nil {
+			if s.merge(d.DeviceId, "", properties, "") {
+				changed = append(changed, d.DeviceId)
+			}
+		}
+	}
+	for id := range s.states {
+		if !ids[id] {
+			delete(s.states, id)
+		}
+	}
+	s.rwmutex.Unlock()
+
+	for _, id := range changed {
+		s.notify(id)
+	}
+}
+
+// deviceResources are the event stream resources whose messages are about the device whose id follows the "/".
+var deviceResources = map[string]bool{
+	"cameras":   true,
+	"chimes":    true,
+	"doorbells": true,
+	"lights":    true,
+	"sirens":    true,
+}
+
+// apply patches the state with a message from the event stream that came from the basestation.
+func (s *stateStore) apply(basestationId string, event *EventStreamResponse) {
+	if event.Action != "is" {
+		return
+	}
+
+	switch resource := event.Resource; {
+	case resource == "basestation":
+		if properties, ok := event.Properties.(map[string]interface{}); ok && basestationId != "" {
+			s.patch(basestationId, "", properties, "")
+		}
+	case resource == "siren":
+		// The built-in siren of the basestation, whose properties would clash with the basestation's own.
+		if properties, ok := event.Properties.(map[string]interface{}); ok && basestationId != "" {
+			s.patch(basestationId, "siren", properties, "")
+		}
+	case resource == "modes":
+		if active := stringProperty(event.Properties, "active"); active != "" && basestationId != "" {
+			s.patch(basestationId, "", nil, active)
+		}
+	case resource == "cameras":
+		// The state of every camera of the basestation, e.g. the response to GetAssociatedCamerasState.
+		cameras, _ := event.Properties.([]interface{})
+		for _, c := range cameras {
+			if properties, ok := c.(map[string]interface{}); ok {
+				if id, _ := properties["serialNumber"].(string); id != "" {
+					s.patch(id, "", properties, "")
+				}
+			}
+		}
+	default:
+		// The state of one device, e.g. "cameras/<deviceId>" or "lights/<deviceId>".
+		i := strings.Index(resource, "/")
+		if i < 0 || !deviceResources[resource[:i]] {
+			return
+		}
+		if properties, ok := event.Properties.(map[string]interface{}); ok && resource[i+1:] != "" {
+			s.patch(resource[i+1:], "", properties, "")
+		}
+	}
+}
+
+// patch merges the properties into the state of the device, and tells its listeners if anything changed. If key isn't
+// "", the properties are merged into that property of the state instead, e.g. the "siren" of a basestation.
+func (s *stateStore) patch(deviceId string, key string, properties map[string]interface{}, mode string) {
+	s.rwmutex.Lock()
+	changed := s.merge(deviceId, key, properties, mode)
+	s.rwmutex.Unlock()
+
+	if changed {
+		s.notify(deviceId)
+	}
+}
+
+// merge merges the properties into the state of the device, or into its property key if key isn't "", and reports
+// whether anything changed. The caller must hold the lock.
+func (s *stateStore) merge(deviceId string, key string, properties map[string]interface{}, mode string) bool {
+	state, ok := s.states[deviceId]
+	if !ok {
+		state = &DeviceState{DeviceId: deviceId, Properties: make(map[string]interface{})}
+		s.states[deviceId] = state
+	}
+
+	changed := !ok
+	target := state.Properties
+	if key != "" && len(properties) > 0 {
+		nested, ok := target[key].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			target[key] = nested
+			changed = true
+		}
+		target = nested
+	}
+	for k, v := range properties {
+		if old, ok := target[k]; !ok || !reflect.DeepEqual(old, v) {
+			target[k] = copyValue(v)
+			changed = true
+		}
+	}
+	if mode != "" && mode != state.Mode {
+		state.Mode = mode
+		changed = true
+	}
+	if changed {
+		state.Updated = time.Now()
+	}
+	return changed
+}
+
+func (s *stateStore) notify(deviceId string) {
+	s.rwmutex.RLock()
+	defer s.rwmutex.RUnlock()
+	state, ok := s.states[deviceId]
+	if !ok {
+		return
+	}
+	for l := range s.listeners {
+		if l.deviceId != "" && l.deviceId != deviceId {
+			continue
+		}
+		select {
+		case l.changes <- state.copy():
+		default:
+		}
+	}
+}
+
+func (s *stateStore) add(deviceId string) *StateListener {
+	changes := make(chan DeviceState, eventBufferSize)
+	l := &StateListener{Changes: changes, changes: changes, deviceId: deviceId, store: s}
+
+	s.rwmutex.Lock()
+	s.listeners[l] = true
+	s.rwmutex.Unlock()
+
+	return l
+}
+
+func (s *stateStore) remove(l *StateListener) {
+	s.rwmutex.Lock()
+	defer s.rwmutex.Unlock()
+	if _, ok := s.listeners[l]; ok {
+		close(l.changes)
+		delete(s.listeners, l)
+	}
+}
+
+// DeviceState returns a copy of the latest known state of the device.
+func (a *Arlo) DeviceState(deviceId string) (state DeviceState, ok bool) {
+	return a.states.get(deviceId)
+}
+
+// DeviceStates returns a copy of the latest known state of every device, by device id.
+func (a *Arlo) DeviceStates() map[string]DeviceState {
+	return a.states.all()
+}
+
+// WatchDeviceState returns a listener that receives a copy of the state of the device every time it changes.
+// An empty device id watches every device. Call Unsubscribe() on the listener when you're done.
+func (a *Arlo) WatchDeviceState(deviceId string) *StateListener {
+	return a.states.add(deviceId)
+}
@@ -0,0 +1,159 @@
+/*
+ * Copyright (c) 2018 Jeffrey Walter <jeffreydwalter@gmail.com>
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated
+ * documentation files (the "Software"), to deal in the Software without restriction, including without limitation the
+ * rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to
+ * permit persons to whom the Software is furnished to do so, subject to the following conditions:
+ * The above copyright notice and this permission notice shall be included in all copies or substantial portions of the
+ * Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE
+ * WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+ * COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR
+ * OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+ */
+
+package arlo
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// The state types are the typed properties of the responses to the "get" event stream messages. Each has the properties
+// as they were received in Raw, for the fields that aren't decoded.
+
+// BasestationState is returned by Basestation.GetState.
+type BasestationState struct {
+	BaseStationMetadata
+	Raw json.RawMessage `json:"-"`
+}
+
+// CameraState is the state of one of the cameras returned by Basestation.GetAssociatedCamerasState.
+type CameraState struct {
+	SerialNumber           string                    `json:"serialNumber"`
+	ModelId                string                    `json:"modelId"`
+	State                  string                    `json:"state"`
+	HwVersion              string                    `json:"hwVersion"`
+	SwVersion              string                    `json:"swVersion"`
+	ConnectionState        string                    `json:"connectionState"`
+	BatteryLevel           int                       `json:"batteryLevel"`
+	BatteryTech            string                    `json:"batteryTech"`
+	ChargerTech            string                    `json:"chargerTech"`
+	ChargingState          string                    `json:"chargingState"`
+	SignalStrength         int                       `json:"signalStrength"`
+	Brightness             int                       `json:"brightness"`
+	Mirror                 bool                      `json:"mirror"`
+	Flip                   bool                      `json:"flip"`
+	PowerSaveMode          int                       `json:"powerSaveMode"`
+	PrivacyActive          bool                      `json:"privacyActive"`
+	MotionSetupModeEnabled bool                      `json:"motionSetupModeEnabled"`
+	MotionDetection        *BaseDetectionProperties  `json:"motionDetection,omitempty"`
+	AudioDetection         *BaseDetectionProperties  `json:"audioDetection,omitempty"`
+	NightLight             *BaseNightLightProperties `json:"nightLight,omitempty"`
+	LastSeen               int64                     `json:"lastSeen,omitempty"` // Milliseconds since the epoch.
+	Raw                    json.RawMessage           `json:"-"`
+}
+
+// A Mode is a set of rules that the basestation runs when it's active. mode0 is disarmed and mode1 is armed.
+type Mode struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Type     string   `json:"type,omitempty"`
+	RulesIds []string `json:"rulesIds"`
+}
+
+// ModesList is returned by Basestation.GetModes.
+type ModesList struct {
+	Active string          `json:"active"`
+	Modes  []Mode          `json:"modes"`
+	Raw    json.RawMessage `json:"-"`
+}
+
+type RuleTrigger struct {
+	Type        string `json:"type"`
+	DeviceId    string `json:"deviceId"`
+	Sensitivity int    `json:"sensitivity,omitempty"`
+}
+
+type RuleAction struct {
+	Type       string   `json:"type"`
+	DeviceId   string   `json:"deviceId,omitempty"`
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// A Rule is what a basestation does (the actions) when something happens (the triggers).
+type Rule struct {
+	Id        string        `json:"id"`
+	Name      string        `json:"name"`
+	Protected bool          `json:"protected"`
+	Triggers  []RuleTrigger `json:"triggers"`
+	Actions   []RuleAction  `json:"actions"`
+}
+
+// RulesList is returned by Basestation.GetRules.
+type RulesList struct {
+	Rules []Rule          `json:"rules"`
+	Raw   json.RawMessage `json:"-"`
+}
+
+// ScheduleState is returned by Basestation.GetCalendarMode. The schedule is left as it was received.
+type ScheduleState struct {
+	Active   bool            `json:"active"`
+	Schedule json.RawMessage `json:"schedule,omitempty"`
+	Raw      json.RawMessage `json:"-"`
+}
+
+type AudioPlaybackConfig struct {
+	ShuffleActive bool   `json:"shuffleActive"`
+	LoopbackMode  string `json:"loopbackMode"`
+	SleepTime     int64  `json:"sleepTime"` // Milliseconds since the epoch.
+	SleepTimeRel  int    `json:"sleepTimeRel"`
+}
+
+type AudioPlaybackStatus struct {
+	Playing  bool   `json:"playing"`
+	TrackId  string `json:"trackId"`
+	Position int    `json:"position"`
+}
+
+// AudioPlaybackState is returned by Camera.GetAudioPlayback.
+type AudioPlaybackState struct {
+	Config AudioPlaybackConfig `json:"config"`
+	Status AudioPlaybackStatus `json:"status"`
+	Raw    json.RawMessage     `json:"-"`
+}
+
+// NightLightState is returned by Camera.GetNightLight.
+type NightLightState struct {
+	BaseNightLightProperties
+	Raw json.RawMessage `json:"-"`
+}
+
+// Decode decodes the properties of the response into v, which is usually a pointer to one of the state types.
+func (r *EventStreamResponse) Decode(v interface{}) error {
+	raw := r.RawProperties
+	if raw == nil {
+		// The response wasn't read from the event stream, e.g. it was made by a mock, so there's nothing raw to decode.
+		var err error
+		if raw, err = json.Marshal(r.Properties); err != nil {
+			return errors.Wrap(err, "failed to encode properties")
+		}
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return errors.Wrap(err, "failed to decode properties")
+	}
+	return nil
+}
+
+// decodeState decodes the properties of a response into v, and keeps them in raw.
+func decodeState(response *EventStreamResponse, v interface{}, raw *json.RawMessage, msg string) error {
+	if err := response.Decode(v); err != nil {
+		return errors.WithMessage(err, msg)
+	}
+	*raw = response.RawProperties
+	return nil
+}
@@ -42,6 +42,26 @@ type FullName struct {
 }
 */
 
+// AuthToken is the token data returned by the Arlo authentication api.
+// If MFA is true and AuthCompleted is false, a second factor is needed before the token can be used.
+type AuthToken struct {
+	Token         string `json:"token"`
+	UserId        string `json:"userId"`
+	Authenticated int64  `json:"authenticated"`
+	Issued        int64  `json:"issued"`
+	ExpiresIn     int64  `json:"expiresIn"`
+	MFA           bool   `json:"mfa"`
+	AuthCompleted bool   `json:"authCompleted"`
+}
+
+// Factor is a second factor (phone number, email address, etc.) that a two-factor authentication code can be sent to.
+type Factor struct {
+	FactorId    string `json:"factorId"`
+	FactorType  string `json:"factorType"`
+	FactorRole  string `json:"factorRole"`
+	DisplayName string `json:"displayName"`
+}
+
 // Account is the account data.
 type Account struct {
 	UserId        string `json:"userId"`
@@ -264,6 +284,33 @@ type CameraProperties struct {
 	Brightness    int  `json:"brightness,omitempty"`
 }
 
+// LightModeProperties is the color of a light: "white" with a color temperature, or "rgb" with a color.
+type LightModeProperties struct {
+	Mode        string                   `json:"mode"`
+	Temperature int                      `json:"temperature,omitempty"`
+	RGB         *NightLightRGBProperties `json:"rgb,omitempty"`
+}
+
+type LightMotionProperties struct {
+	Enabled     bool `json:"enabled"`
+	Sensitivity int  `json:"sensitivity,omitempty"`
+	Duration    int  `json:"duration,omitempty"`
+}
+
+type LightFlashProperties struct {
+	Pattern string `json:"pattern"`
+}
+
+// LightProperties is the Properties struct for the EventStreamPayload type, for devices of type "lights".
+type LightProperties struct {
+	LampState  string                 `json:"lampState,omitempty"`
+	Brightness int                    `json:"brightness,omitempty"`
+	Duration   int                    `json:"duration,omitempty"`
+	LightMode  *LightModeProperties   `json:"lightMode,omitempty"`
+	Motion     *LightMotionProperties `json:"motionActivated,omitempty"`
+	Flash      *LightFlashProperties  `json:"flash,omitempty"`
+}
+
 // EventStreamPayload is the message that will be sent to the arlo servers via the /notify API.
 type EventStreamPayload struct {
 	Action          string      `json:"action,omitempty"`
@@ -17,6 +17,7 @@
 package arlo
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math"
@@ -44,7 +45,7 @@ func checkRequest(resp *request.Response, err error, msg string) error {
 	}
 
 	if status.Success == false {
-		return errors.WithMessage(errors.New(status.Reason), msg)
+		return errors.WithMessage(statusError(resp, status), msg)
 	}
 
 	return nil
@@ -62,19 +63,27 @@ func genTransId() string {
 	return fmt.Sprintf("%s!%s!%s", TransIdPrefix, strings.ToLower(util.FloatToHex(e)), strconv.Itoa(int(ms)))
 }
 
-func (a *Arlo) get(uri, xCloudId string, header http.Header) (*request.Response, error) {
-	a.client.AddHeader("xcloudId", xCloudId)
-	return a.client.Get(uri, header)
+// withXCloudId returns a copy of header with the xcloudId of the device that a request is for. It's set on each
+// request, rather than on the client, since requests for different basestations are made at the same time.
+func withXCloudId(header http.Header, xCloudId string) http.Header {
+	h := header.Clone()
+	if h == nil {
+		h = make(http.Header)
+	}
+	h.Set("xcloudId", xCloudId)
+	return h
+}
+
+func (a *Arlo) get(ctx context.Context, uri, xCloudId string, header http.Header) (*request.Response, error) {
+	return a.client.GetContext(ctx, uri, withXCloudId(header, xCloudId))
 }
 
-func (a *Arlo) put(uri, xCloudId string, body interface{}, header http.Header) (*request.Response, error) {
-	a.client.AddHeader("xcloudId", xCloudId)
-	return a.client.Put(uri, body, header)
+func (a *Arlo) put(ctx context.Context, uri, xCloudId string, body interface{}, header http.Header) (*request.Response, error) {
+	return a.client.PutContext(ctx, uri, body, withXCloudId(header, xCloudId))
 }
 
-func (a *Arlo) post(uri, xCloudId string, body interface{}, header http.Header) (*request.Response, error) {
-	a.client.AddHeader("xcloudId", xCloudId)
-	return a.client.Post(uri, body, header)
+func (a *Arlo) post(ctx context.Context, uri, xCloudId string, body interface{}, header http.Header) (*request.Response, error) {
+	return a.client.PostContext(ctx, uri, body, withXCloudId(header, xCloudId))
 }
 
 /*
@@ -102,15 +111,42 @@ func (a *Arlo) DownloadFile(url, to string) error {
 */
 
 func (a *Arlo) DownloadFile(url string, w io.Writer) error {
+	return a.DownloadFileContext(context.Background(), url, w)
+}
+
+// DownloadFileContext is like DownloadFile, but uses ctx for the request.
+func (a *Arlo) DownloadFileContext(ctx context.Context, url string, w io.Writer) error {
+	return a.download(ctx, url, w)
+}
+
+// download streams the file at url (usually a presigned url to AWS) into w.
+func (a *Arlo) download(ctx context.Context, url string, w io.Writer) error {
 	msg := fmt.Sprintf("failed to download file (%s)", url)
 
-	resp, err := http.Get(url)
+	release, err := a.client.Limiter().Acquire(ctx)
+	if err != nil {
+		return errors.WithMessage(err, msg)
+	}
+	defer release()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return errors.WithMessage(err, msg)
+	}
+
+	// Use the configured transport, but not the cookies, since the file is usually on another host.
+	client := &http.Client{Transport: a.client.HttpClient.Transport}
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return errors.WithMessage(err, msg)
 	}
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.WithMessage(&APIError{StatusCode: resp.StatusCode, Endpoint: req.Method + " " + req.URL.Path}, msg)
+	}
+
 	_, err = io.Copy(w, resp.Body)
 	if err != nil {
 		return errors.WithMessage(err, msg)
@@ -119,6 +155,16 @@ func (a *Arlo) DownloadFile(url string, w io.Writer) error {
 	return nil
 }
 
+// stringProperty returns the string value of key in the properties of an event stream message, or "" if there isn't one.
+func stringProperty(properties interface{}, key string) string {
+	m, ok := properties.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	s, _ := m[key].(string)
+	return s
+}
+
 func FromUnixMicro(µs int64) time.Time { return time.Unix(0, 1000*µs) }
 
 func FromUnixMilli(ms int64) time.Time { return time.Unix(0, 1000000*ms) }